@@ -0,0 +1,151 @@
+package vkoauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Ошибки авторизации, которые возвращает API
+// *ApiError с соответствующим кодом можно проверить с помощью errors.Is(err, ErrApiAuthorizationFailed)
+var (
+	ErrApiAuthorizationFailed      = errors.New("user authorization failed")        // Код 5: токен недействителен или отозван
+	ErrApiPermissionDenied         = errors.New("permission denied")                // Код 7: у токена нет нужных прав
+	ErrApiCaptchaNeeded            = errors.New("captcha needed")                   // Код 14: нужно ввести капчу (CaptchaSid, CaptchaImg)
+	ErrApiAccessDenied             = errors.New("access denied")                    // Код 15: доступ к объекту запрещен
+	ErrApiValidationRequired       = errors.New("validation required")              // Код 17: нужно пройти валидацию по RedirectUri
+	ErrApiGroupAuthorizationFailed = errors.New("group authorization failed")       // Код 27: метод недоступен с токеном сообщества
+	ErrApiAppAuthorizationFailed   = errors.New("application authorization failed") // Код 28: метод недоступен с токеном приложения
+)
+
+var apiErrors = map[int]error{
+	5:  ErrApiAuthorizationFailed,
+	7:  ErrApiPermissionDenied,
+	14: ErrApiCaptchaNeeded,
+	15: ErrApiAccessDenied,
+	17: ErrApiValidationRequired,
+	27: ErrApiGroupAuthorizationFailed,
+	28: ErrApiAppAuthorizationFailed,
+}
+
+// Ошибка, которую вернул метод API
+type ApiError struct {
+	Response    *http.Response
+	Body        []byte
+	Code        int    // Код ошибки (error_code)
+	Message     string // Описание ошибки (error_msg)
+	CaptchaSid  string // Идентификатор капчи (код 14)
+	CaptchaImg  string // Ссылка на изображение капчи (код 14)
+	RedirectUri string // Ссылка на страницу валидации (код 17)
+}
+
+func (e *ApiError) Error() string {
+	return fmt.Sprintf("API error: %d %s", e.Code, e.Message)
+}
+
+// Возвращает типизированную ошибку авторизации (ErrApiAuthorizationFailed и т.д.) или nil
+func (e *ApiError) Unwrap() error {
+	return apiErrors[e.Code]
+}
+
+type apiResponseJson struct {
+	Response json.RawMessage `json:"response"`
+	Error    *struct {
+		ErrorCode   int    `json:"error_code"`
+		ErrorMsg    string `json:"error_msg"`
+		CaptchaSid  string `json:"captcha_sid"`
+		CaptchaImg  string `json:"captcha_img"`
+		RedirectUri string `json:"redirect_uri"`
+	} `json:"error"`
+}
+
+// Возвращает базовый URL методов API
+func (v *Config) apiUrl() string {
+	if u := v.endpoint().ApiUrl; u != "" {
+		return u
+	}
+	return DefaultVkEndpoint.ApiUrl
+}
+
+// Вызывает метод API method с параметрами params и декодирует поле response в result (если result не nil)
+// Ключ доступа берется из ts (если ts не nil), версия API - из Config.Version, если она не указана в params
+// Возвращает *ApiError, если API вернул ошибку
+// Если API отклонил ключ доступа (код 5), ключ помечается недействительным (TokenInvalidator),
+// вызывается Config.OnTokenInvalid и возвращается *InvalidTokenError
+// Смотрите документацию: https://dev.vk.com/api/api-requests
+func (v *Config) CallMethod(ctx context.Context, ts TokenSource, method string, params url.Values, result interface{}) error {
+	client := ContextClient(ctx)
+	if client == nil {
+		return fmt.Errorf("http client is nil")
+	}
+
+	body := url.Values{}
+	for k, vals := range params {
+		body[k] = vals
+	}
+
+	var token *Token
+	if ts != nil {
+		var err error
+		token, err = ts.Token(ctx)
+		if err != nil {
+			return fmt.Errorf("get access token error: %w", err)
+		}
+		body.Set("access_token", token.AccessToken)
+	}
+	if body.Get("v") == "" {
+		body.Set("v", v.version())
+	}
+
+	req, err := newContextRequest(ctx, http.MethodPost, strings.TrimSuffix(v.apiUrl(), "/")+"/"+method, strings.NewReader(body.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	b, err := readResponse(res)
+	if err != nil {
+		return err
+	}
+
+	apiResponse := apiResponseJson{}
+	if err := json.Unmarshal(b, &apiResponse); err != nil {
+		return newResponseError(res, b, err, "parse %s response", method)
+	}
+
+	if apiResponse.Error != nil {
+		apiErr := &ApiError{
+			Response:    res,
+			Body:        b,
+			Code:        apiResponse.Error.ErrorCode,
+			Message:     apiResponse.Error.ErrorMsg,
+			CaptchaSid:  apiResponse.Error.CaptchaSid,
+			CaptchaImg:  apiResponse.Error.CaptchaImg,
+			RedirectUri: apiResponse.Error.RedirectUri,
+		}
+
+		if token != nil && errors.Is(apiErr, ErrApiAuthorizationFailed) {
+			return v.invalidateToken(ctx, ts, token, apiErr)
+		}
+		return apiErr
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(apiResponse.Response, result); err != nil {
+		return fmt.Errorf("parse %s response error: %w", method, err)
+	}
+
+	return nil
+}
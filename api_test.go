@@ -0,0 +1,93 @@
+package vkoauth_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/ciricc/vkoauth"
+)
+
+func TestCallMethod(t *testing.T) {
+	var form url.Values
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/method/users.get" {
+			t.Errorf("unexpected request path: %q", r.URL.Path)
+		}
+		r.ParseForm()
+		form = r.PostForm
+		w.Write([]byte(`{"response":[{"id":1,"first_name":"Павел"}]}`))
+	}))
+	defer serv.Close()
+
+	c := apiConf(serv.URL)
+	params := url.Values{}
+	params.Set("user_ids", "1")
+
+	users := []struct {
+		Id        int64  `json:"id"`
+		FirstName string `json:"first_name"`
+	}{}
+	err := c.CallMethod(context.Background(), vkoauth.StaticTokenSource("USER_TOKEN"), "users.get", params, &users)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(users) != 1 || users[0].Id != 1 || users[0].FirstName != "Павел" {
+		t.Errorf("unexpected response: %+v", users)
+	}
+	if form.Get("access_token") != "USER_TOKEN" || form.Get("v") != "VERSION" || form.Get("user_ids") != "1" {
+		t.Errorf("unexpected form: %v", form)
+	}
+	if params.Get("access_token") != "" {
+		t.Errorf("params must not be modified")
+	}
+}
+
+func TestCallMethodTokenSourceError(t *testing.T) {
+	c := apiConf("http://localhost")
+	err := c.CallMethod(context.Background(), vkoauth.StaticTokenSource(""), "users.get", nil, nil)
+	if err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestCallMethodAuthErrors(t *testing.T) {
+	tests := []struct {
+		body string
+		err  error
+	}{
+		{`{"error":{"error_code":5,"error_msg":"User authorization failed: invalid access_token (4)."}}`, vkoauth.ErrApiAuthorizationFailed},
+		{`{"error":{"error_code":15,"error_msg":"Access denied"}}`, vkoauth.ErrApiAccessDenied},
+		{`{"error":{"error_code":17,"error_msg":"Validation required","redirect_uri":"https://vk.com/validate"}}`, vkoauth.ErrApiValidationRequired},
+		{`{"error":{"error_code":27,"error_msg":"Group authorization failed"}}`, vkoauth.ErrApiGroupAuthorizationFailed},
+		{`{"error":{"error_code":28,"error_msg":"Application authorization failed"}}`, vkoauth.ErrApiAppAuthorizationFailed},
+		{`{"error":{"error_code":100,"error_msg":"One of the parameters specified was missing or invalid"}}`, nil},
+	}
+
+	for _, test := range tests {
+		serv := apiServer(t, map[string]string{"/method/users.get": test.body})
+		c := apiConf(serv.URL)
+
+		err := c.CallMethod(context.Background(), vkoauth.StaticTokenSource("USER_TOKEN"), "users.get", nil, nil)
+		serv.Close()
+
+		apiErr := &vkoauth.ApiError{}
+		if !errors.As(err, &apiErr) {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		if test.err != nil && !errors.Is(err, test.err) {
+			t.Errorf("expected %v, got %v", test.err, err)
+		}
+		if test.err == nil && errors.Unwrap(err) != nil {
+			t.Errorf("unexpected typed error: %v", errors.Unwrap(err))
+		}
+		if apiErr.Code == 17 && apiErr.RedirectUri != "https://vk.com/validate" {
+			t.Errorf("unexpected redirect uri: %q", apiErr.RedirectUri)
+		}
+	}
+}
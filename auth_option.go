@@ -1,6 +1,12 @@
 package vkoauth
 
-import "net/url"
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ciricc/vkoauth/scope"
+)
 
 // Интерфейс опции авторизации
 type AuthOption interface {
@@ -17,3 +23,38 @@ func (v setParam) setValue(u url.Values) {
 func SetUrlParam(key, val string) AuthOption {
 	return setParam{key, val}
 }
+
+// Опция, которая задает язык (параметр lang), например для запросов получения токена:
+// ВКонтакте вернет error_description на этом языке
+func Lang(lang string) AuthOption {
+	return setParam{"lang", lang}
+}
+
+type scopeNames struct{}
+
+func (v scopeNames) setValue(u url.Values) {
+	mask, err := strconv.ParseUint(u.Get("scope"), 10, 64)
+	if err != nil || mask == 0 {
+		return
+	}
+
+	var names []string
+	if u.Get("group_ids") != "" {
+		names = scope.GroupScope(mask).Names()
+	} else {
+		names = scope.Scope(mask).Names()
+	}
+
+	// Биты без названия можно передать только битовой маской
+	if _, err := strconv.ParseUint(names[len(names)-1], 10, 64); err == nil {
+		return
+	}
+
+	u.Set("scope", strings.Join(names, ","))
+}
+
+// Опция, которая записывает права в URL авторизации названиями (scope=friends,photos) вместо битовой маски
+// Если у части прав нет названия, права остаются битовой маской
+func ScopeNames() AuthOption {
+	return scopeNames{}
+}
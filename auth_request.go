@@ -0,0 +1,70 @@
+package vkoauth
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ciricc/vkoauth/display"
+	"github.com/ciricc/vkoauth/scope"
+)
+
+// Параметры URL авторизации, разобранные ParseAuthUrl
+type AuthRequest struct {
+	ClientId     string
+	RedirectUri  string
+	ResponseType string // token (Implicit Flow) или code (Authorization Code Flow)
+	Version      string
+	State        string
+	Revoke       bool
+	Display      display.Display
+	Lang         string // Язык страницы авторизации и описаний ошибок ("", если не указан)
+	GroupIds     []int64
+	Scope        scope.Scope      // Права пользователя (если GroupIds не указаны)
+	GroupScope   scope.GroupScope // Права сообществ (если указаны GroupIds)
+	Query        url.Values       // Все параметры URL
+}
+
+// Разбирает URL авторизации, созданный ImplicitFlowAuthUrl или CodeFlowAuthUrl
+// Параметр scope может быть записан как битовой маской, так и названиями прав (см. ScopeNames)
+func ParseAuthUrl(rawUrl string) (*AuthRequest, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	q := u.Query()
+	req := &AuthRequest{
+		ClientId:     q.Get("client_id"),
+		RedirectUri:  q.Get("redirect_uri"),
+		ResponseType: q.Get("response_type"),
+		Version:      q.Get("v"),
+		State:        q.Get("state"),
+		Revoke:       q.Get("revoke") == "1",
+		Display:      display.Display(q.Get("display")),
+		Lang:         q.Get("lang"),
+		Query:        q,
+	}
+
+	if ids := q.Get("group_ids"); ids != "" {
+		for _, id := range strings.Split(ids, ",") {
+			groupId, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parse group_ids field error: %w", err)
+			}
+			req.GroupIds = append(req.GroupIds, groupId)
+		}
+	}
+
+	if len(req.GroupIds) > 0 {
+		req.GroupScope, err = scope.ParseGroup(q.Get("scope"))
+	} else {
+		req.Scope, err = scope.Parse(q.Get("scope"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse scope field error: %w", err)
+	}
+
+	return req, nil
+}
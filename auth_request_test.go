@@ -0,0 +1,95 @@
+package vkoauth_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ciricc/vkoauth"
+	"github.com/ciricc/vkoauth/display"
+	"github.com/ciricc/vkoauth/scope"
+)
+
+func TestScopeNamesOption(t *testing.T) {
+	c := conf("")
+	c.Scope = scope.User.Friends | scope.User.Photos
+	c.GroupScope = scope.Group.Manage
+
+	u, err := c.ImplicitFlowAuthUrl(vkoauth.AuthParams{}, vkoauth.ScopeNames())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u != "?client_id=CLIENT_ID&redirect_uri=REDIRECT_URI&response_type=token&scope=friends%2Cphotos&v=VERSION" {
+		t.Errorf("unexpected url: %q", u)
+	}
+
+	u, err = c.ImplicitFlowAuthUrl(vkoauth.AuthParams{GroupIds: []int64{1}}, vkoauth.ScopeNames())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u != "?client_id=CLIENT_ID&group_ids=1&redirect_uri=REDIRECT_URI&response_type=token&scope=manage&v=VERSION" {
+		t.Errorf("unexpected url: %q", u)
+	}
+
+	// Бит без названия нельзя записать названием
+	c.Scope = scope.User.Wall | scope.User.Plus256
+	u, _ = c.CodeFlowAuthUrl(vkoauth.AuthParams{}, vkoauth.ScopeNames())
+	if u != "?client_id=CLIENT_ID&redirect_uri=REDIRECT_URI&response_type=code&scope=8448&v=VERSION" {
+		t.Errorf("unexpected url: %q", u)
+	}
+}
+
+func TestParseAuthUrl(t *testing.T) {
+	c := conf("https://oauth.vk.com/authorize")
+	c.Scope = scope.User.Friends | scope.User.Offline
+	c.GroupScope = scope.Group.Photos | scope.Group.Manage
+
+	params := vkoauth.AuthParams{State: "state", Revoke: true, Display: display.Mobile, Lang: vkoauth.LangEn}
+	for _, opts := range [][]vkoauth.AuthOption{nil, {vkoauth.ScopeNames()}} {
+		u, err := c.ImplicitFlowAuthUrl(params, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req, err := vkoauth.ParseAuthUrl(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if req.ClientId != "CLIENT_ID" || req.RedirectUri != "REDIRECT_URI" || req.ResponseType != "token" ||
+			req.Version != "VERSION" || req.State != "state" || !req.Revoke || req.Display != display.Mobile || req.Lang != vkoauth.LangEn {
+			t.Errorf("unexpected request: %+v", req)
+		}
+
+		if req.Scope != c.Scope || req.GroupScope != 0 {
+			t.Errorf("unexpected scope in %q: %d %d", u, req.Scope, req.GroupScope)
+		}
+	}
+
+	for _, opts := range [][]vkoauth.AuthOption{nil, {vkoauth.ScopeNames()}} {
+		u, err := c.CodeFlowAuthUrl(vkoauth.AuthParams{GroupIds: []int64{1, 2}}, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req, err := vkoauth.ParseAuthUrl(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(req.GroupIds, []int64{1, 2}) || req.GroupScope != c.GroupScope || req.Scope != 0 {
+			t.Errorf("unexpected request for %q: %+v", u, req)
+		}
+	}
+}
+
+func TestParseAuthUrlErrors(t *testing.T) {
+	for _, u := range []string{
+		"https://oauth.vk.com/authorize?scope=friends,unknown",
+		"https://oauth.vk.com/authorize?group_ids=1,a",
+		"https://oauth.vk.com/authorize?group_ids=1&scope=offline",
+	} {
+		if _, err := vkoauth.ParseAuthUrl(u); err == nil {
+			t.Errorf("expected error for %q", u)
+		}
+	}
+}
@@ -9,8 +9,9 @@ import (
 // Создает URL, на который нужно направить пользователя для проведения авторизации методом Authorization Code Flow
 // После проведения авторизации, пользователь перейдет на redirect_uri, куда будет отправлен параметр code
 // Вам нужно взять значение этого параметра и использовать его в методе config.ExchangeCode(context.Background(), code)
+// Возвращает ошибку, если параметры авторизации некорректны (например, ErrMixedScope)
 // Смотрите документацию: https://dev.vk.com/api/access-token/authcode-flow-user
-func (v *Config) CodeFlowAuthUrl(params AuthParams, opts ...AuthOption) string {
+func (v *Config) CodeFlowAuthUrl(params AuthParams, opts ...AuthOption) (string, error) {
 	return v.buildAuthUrl(params, "code", opts...)
 }
 
@@ -23,9 +24,9 @@ func (v *Config) ExchangeCode(ctx context.Context, code string, opts ...AuthOpti
 	}
 
 	exchangeOptions = append(exchangeOptions, opts...)
-	return v.doTokenRequest(ctx, v.buildTokenUrl(v.endpoint().TokenUrl,
+	return v.tokenRequest(ctx, tokenUrl,
 		exchangeOptions...,
-	))
+	)
 }
 
 // Возвращает код, полученный сервером после редиректа пользователя
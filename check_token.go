@@ -0,0 +1,81 @@
+package vkoauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Результат проверки токена пользователя методом secure.checkToken
+type CheckTokenResult struct {
+	Valid   bool       // Токен действителен
+	UserId  int64      // Идентификатор пользователя, которому выдан токен
+	Issued  time.Time  // Дата выдачи токена
+	Expires *time.Time // Дата истечения токена (nil, если токен бессрочный)
+}
+
+type checkTokenJson struct {
+	Success int   `json:"success"`
+	UserId  int64 `json:"user_id"`
+	Date    int64 `json:"date"`
+	Expire  int64 `json:"expire"`
+}
+
+// Проверяет токен пользователя методом secure.checkToken
+// Сервисный ключ берется из Config.ServiceToken, если он не указан - запрашивается через GetServiceToken при каждом вызове
+// Если API ответил ошибкой авторизации (коды 5 и 15) и у SecretSource есть предыдущий ключ,
+// запрос повторяется один раз с предыдущим ключом
+// ip - IP адрес пользователя, необязательный параметр
+// Возвращает *ApiError, если API вернул ошибку (например, если токен недействителен)
+// Смотрите документацию: https://dev.vk.com/method/secure.checkToken
+func (v *Config) CheckToken(ctx context.Context, token string, ip string) (*CheckTokenResult, error) {
+	secrets, err := v.secrets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get client secret error: %w", err)
+	}
+
+	ts := v.ServiceToken
+	if ts == nil {
+		ts = v.ServiceTokenSource()
+	}
+
+	res, err := v.checkToken(ctx, ts, token, ip, secrets.Primary)
+	if err != nil && secrets.Previous != "" && secrets.Previous != secrets.Primary &&
+		(errors.Is(err, ErrApiAuthorizationFailed) || errors.Is(err, ErrApiAccessDenied)) {
+		if res, retryErr := v.checkToken(ctx, ts, token, ip, secrets.Previous); retryErr == nil {
+			return res, nil
+		}
+	}
+
+	return res, err
+}
+
+func (v *Config) checkToken(ctx context.Context, ts TokenSource, token, ip, secret string) (*CheckTokenResult, error) {
+	params := url.Values{}
+	params.Set("token", token)
+	params.Set("client_secret", secret)
+
+	if ip != "" {
+		params.Set("ip", ip)
+	}
+
+	res := checkTokenJson{}
+	if err := v.CallMethod(ctx, ts, "secure.checkToken", params, &res); err != nil {
+		return nil, err
+	}
+
+	result := &CheckTokenResult{
+		Valid:  res.Success == 1,
+		UserId: res.UserId,
+		Issued: time.Unix(res.Date, 0),
+	}
+
+	if res.Expire != 0 {
+		e := time.Unix(res.Expire, 0)
+		result.Expires = &e
+	}
+
+	return result, nil
+}
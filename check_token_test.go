@@ -0,0 +1,170 @@
+package vkoauth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ciricc/vkoauth"
+)
+
+func apiServer(t *testing.T, methods map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/access_token" {
+			w.Write([]byte(`{"access_token":"SERVICE_TOKEN"}`))
+			return
+		}
+
+		body, ok := methods[r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected request path: %q", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		r.ParseForm()
+		if r.PostForm.Get("v") != "VERSION" {
+			t.Errorf("unexpected version: %q", r.PostForm.Get("v"))
+		}
+
+		w.Write([]byte(body))
+	}))
+}
+
+func apiConf(u string) vkoauth.Config {
+	c := conf(u)
+	c.Endpoint.TokenUrl = u + "/access_token"
+	c.Endpoint.ApiUrl = u + "/method"
+	return c
+}
+
+func TestCheckToken(t *testing.T) {
+	var form map[string]string
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/access_token" {
+			w.Write([]byte(`{"access_token":"SERVICE_TOKEN"}`))
+			return
+		}
+		r.ParseForm()
+		form = map[string]string{}
+		for k := range r.PostForm {
+			form[k] = r.PostForm.Get(k)
+		}
+		w.Write([]byte(`{"response":{"success":1,"user_id":66748,"date":1666699200,"expire":1666785600}}`))
+	}))
+	defer serv.Close()
+
+	c := apiConf(serv.URL)
+	res, err := c.CheckToken(context.Background(), "USER_TOKEN", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedForm := map[string]string{
+		"token":         "USER_TOKEN",
+		"ip":            "127.0.0.1",
+		"access_token":  "SERVICE_TOKEN",
+		"client_secret": "CLIENT_SECRET",
+		"v":             "VERSION",
+	}
+	for k, v := range expectedForm {
+		if form[k] != v {
+			t.Errorf("unexpected %s: %q", k, form[k])
+		}
+	}
+
+	if !res.Valid || res.UserId != 66748 || res.Issued.Unix() != 1666699200 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if res.Expires == nil || res.Expires.Unix() != 1666785600 {
+		t.Errorf("unexpected expires: %v", res.Expires)
+	}
+}
+
+func TestCheckTokenNoExpire(t *testing.T) {
+	serv := apiServer(t, map[string]string{
+		"/method/secure.checkToken": `{"response":{"success":1,"user_id":66748,"date":1666699200,"expire":0}}`,
+	})
+	defer serv.Close()
+
+	c := apiConf(serv.URL)
+	res, err := c.CheckToken(context.Background(), "USER_TOKEN", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Expires != nil {
+		t.Errorf("unexpected expires: %v", res.Expires)
+	}
+}
+
+func TestCheckTokenError(t *testing.T) {
+	serv := apiServer(t, map[string]string{
+		"/method/secure.checkToken": `{"error":{"error_code":15,"error_msg":"Access denied: invalid token"}}`,
+	})
+	defer serv.Close()
+
+	c := apiConf(serv.URL)
+	_, err := c.CheckToken(context.Background(), "USER_TOKEN", "")
+	apiErr, ok := err.(*vkoauth.ApiError)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiErr.Code != 15 || apiErr.Message != "Access denied: invalid token" {
+		t.Errorf("unexpected error: %+v", apiErr)
+	}
+}
+
+func TestCheckTokenCachedServiceToken(t *testing.T) {
+	tokenRequests := 0
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/access_token" {
+			tokenRequests++
+			w.Write([]byte(`{"access_token":"SERVICE_TOKEN"}`))
+			return
+		}
+		w.Write([]byte(`{"response":{"success":1,"user_id":66748,"date":1666699200}}`))
+	}))
+	defer serv.Close()
+
+	c := apiConf(serv.URL)
+	c.ServiceToken = vkoauth.CachedTokenSource(c.ServiceTokenSource())
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.CheckToken(context.Background(), "USER_TOKEN", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tokenRequests != 1 {
+		t.Errorf("service token must be requested once, got %d", tokenRequests)
+	}
+}
+
+func TestCheckTokenPreviousSecret(t *testing.T) {
+	secrets := []string{}
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/access_token" {
+			w.Write([]byte(`{"access_token":"SERVICE_TOKEN"}`))
+			return
+		}
+		r.ParseForm()
+		secrets = append(secrets, r.PostForm.Get("client_secret"))
+		if r.PostForm.Get("client_secret") != "old" {
+			w.Write([]byte(`{"error":{"error_code":15,"error_msg":"Access denied: invalid client_secret"}}`))
+			return
+		}
+		w.Write([]byte(`{"response":{"success":1,"user_id":66748,"date":1666699200}}`))
+	}))
+	defer serv.Close()
+
+	c := apiConf(serv.URL)
+	c.SecretSource = vkoauth.StaticSecret("new", "old")
+
+	res, err := c.CheckToken(context.Background(), "USER_TOKEN", "")
+	if err != nil || !res.Valid {
+		t.Fatalf("unexpected result: %+v %v", res, err)
+	}
+	if len(secrets) != 2 || secrets[0] != "new" || secrets[1] != "old" {
+		t.Errorf("unexpected secrets: %v", secrets)
+	}
+}
@@ -11,7 +11,7 @@ func (v *Config) GetServiceToken(ctx context.Context, opts ...AuthOption) (*Toke
 	}
 
 	credentialsOptions = append(credentialsOptions, opts...)
-	return v.doTokenRequest(ctx, v.buildTokenUrl(v.endpoint().TokenUrl,
+	return v.tokenRequest(ctx, tokenUrl,
 		credentialsOptions...,
-	))
+	)
 }
@@ -0,0 +1,232 @@
+package vkoauth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ciricc/vkoauth/scope"
+)
+
+// Загрузчик Config из JSON файла и переменных окружения
+//
+// Приоритет значений (от низшего к высшему): значения по умолчанию (DefaultVersion, DefaultVkEndpoint),
+// JSON файл File, переменные окружения с префиксом EnvPrefix:
+//
+//	{EnvPrefix}CLIENT_ID, {EnvPrefix}CLIENT_SECRET, {EnvPrefix}VERSION, {EnvPrefix}REDIRECT_URI,
+//	{EnvPrefix}SCOPE, {EnvPrefix}GROUP_SCOPE (названия через запятую или битовая маска),
+//	{EnvPrefix}AUTH_URL, {EnvPrefix}TOKEN_URL, {EnvPrefix}PASSWORD_TOKEN_URL, {EnvPrefix}API_URL,
+//	{EnvPrefix}ENDPOINT (название набора адресов из Endpoints), {EnvPrefix}FALLBACK_ENDPOINTS (названия через запятую)
+//
+// Отдельные адреса (AUTH_URL и т.д.) перезаписывают адреса из набора ENDPOINT
+// Если ENDPOINT задан в окружении, адреса из файла (endpoint_preset и endpoint) не используются
+//
+// Пустая переменная окружения считается заданной и перезаписывает значение из файла
+type ConfigLoader struct {
+	EnvPrefix string                          // Префикс переменных окружения, например "VK_"
+	File      string                          // Путь к JSON файлу (необязательно)
+	Flow      Flow                            // Способ авторизации, для которого вызывается Validate (0 - без проверки)
+	LookupEnv func(key string) (string, bool) // Источник переменных окружения (os.LookupEnv, если не указан)
+}
+
+// JSON схема файла конфигурации
+type configJson struct {
+	ClientId     configClientId    `json:"client_id"`
+	ClientSecret *string           `json:"client_secret"`
+	Version      *string           `json:"version"`
+	RedirectUri  *string           `json:"redirect_uri"`
+	Scope        *scope.Scope      `json:"scope"`
+	GroupScope   *scope.GroupScope `json:"group_scope"`
+	// Название набора адресов из Endpoints
+	EndpointPreset *string `json:"endpoint_preset"`
+	// Названия запасных наборов адресов из Endpoints
+	FallbackEndpoints []string `json:"fallback_endpoints"`
+	Endpoint          *struct {
+		AuthUrl          *string `json:"auth_url"`
+		PasswordTokenUrl *string `json:"password_token_url"`
+		TokenUrl         *string `json:"token_url"`
+		ApiUrl           *string `json:"api_url"`
+	} `json:"endpoint"`
+}
+
+// Идентификатор приложения, в файле может быть записан строкой или числом
+type configClientId struct {
+	value *string
+}
+
+func (c *configClientId) UnmarshalJSON(b []byte) error {
+	s := ""
+	if err := json.Unmarshal(b, &s); err == nil {
+		c.value = &s
+		return nil
+	}
+
+	n := json.Number("")
+	if err := json.Unmarshal(b, &n); err != nil {
+		return fmt.Errorf("client_id must be a string or a number: %s", string(b))
+	}
+
+	s = n.String()
+	c.value = &s
+	return nil
+}
+
+// Загружает Config из переменных окружения с префиксом prefix и JSON файла file (необязательно)
+// и проверяет его для способа авторизации flow
+func LoadConfig(prefix string, file string, flow Flow) (*Config, error) {
+	return ConfigLoader{EnvPrefix: prefix, File: file, Flow: flow}.Load()
+}
+
+// Загружает Config и, если указан Flow, проверяет его с помощью Config.Validate
+func (l ConfigLoader) Load() (*Config, error) {
+	c := &Config{}
+	data := configJson{}
+
+	if l.File != "" {
+		b, err := os.ReadFile(l.File)
+		if err != nil {
+			return nil, err
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&data); err != nil {
+			return nil, fmt.Errorf("parse config file %s error: %w", l.File, err)
+		}
+
+		setString(&c.ClientId, data.ClientId.value)
+		setString(&c.ClientSecret, data.ClientSecret)
+		setString(&c.Version, data.Version)
+		setString(&c.RedirectUri, data.RedirectUri)
+
+		if data.Scope != nil {
+			c.Scope = *data.Scope
+		}
+
+		if data.GroupScope != nil {
+			c.GroupScope = *data.GroupScope
+		}
+
+	}
+
+	lookup := l.LookupEnv
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+
+	env := func(name string, dst *string) bool {
+		if val, ok := lookup(l.EnvPrefix + name); ok {
+			*dst = val
+			return true
+		}
+		return false
+	}
+
+	env("CLIENT_ID", &c.ClientId)
+	env("CLIENT_SECRET", &c.ClientSecret)
+	env("VERSION", &c.Version)
+	env("REDIRECT_URI", &c.RedirectUri)
+
+	val := ""
+	if env("SCOPE", &val) {
+		s, err := scope.Parse(val)
+		if err != nil {
+			return nil, fmt.Errorf("parse %sSCOPE error: %w", l.EnvPrefix, err)
+		}
+		c.Scope = s
+	}
+
+	if env("GROUP_SCOPE", &val) {
+		s, err := scope.ParseGroup(val)
+		if err != nil {
+			return nil, fmt.Errorf("parse %sGROUP_SCOPE error: %w", l.EnvPrefix, err)
+		}
+		c.GroupScope = s
+	}
+
+	endpoint, customEndpoint := *DefaultVkEndpoint, false
+	if data.EndpointPreset != nil {
+		e, err := endpointPreset(*data.EndpointPreset)
+		if err != nil {
+			return nil, err
+		}
+		endpoint, customEndpoint = *e, true
+	}
+
+	if e := data.Endpoint; e != nil {
+		customEndpoint = true
+		setString(&endpoint.AuthUrl, e.AuthUrl)
+		setString(&endpoint.PasswordTokenUrl, e.PasswordTokenUrl)
+		setString(&endpoint.TokenUrl, e.TokenUrl)
+		setString(&endpoint.ApiUrl, e.ApiUrl)
+	}
+
+	// Набор адресов из окружения заменяет все адреса из файла
+	if env("ENDPOINT", &val) {
+		e, err := endpointPreset(val)
+		if err != nil {
+			return nil, err
+		}
+		endpoint, customEndpoint = *e, true
+	}
+
+	for _, e := range []struct {
+		name string
+		dst  *string
+	}{
+		{"AUTH_URL", &endpoint.AuthUrl},
+		{"PASSWORD_TOKEN_URL", &endpoint.PasswordTokenUrl},
+		{"TOKEN_URL", &endpoint.TokenUrl},
+		{"API_URL", &endpoint.ApiUrl},
+	} {
+		if env(e.name, e.dst) {
+			customEndpoint = true
+		}
+	}
+
+	if customEndpoint {
+		c.Endpoint = &endpoint
+	}
+
+	fallbacks := data.FallbackEndpoints
+	if env("FALLBACK_ENDPOINTS", &val) {
+		fallbacks = nil
+		for _, name := range strings.Split(val, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				fallbacks = append(fallbacks, name)
+			}
+		}
+	}
+
+	for _, name := range fallbacks {
+		e, err := endpointPreset(name)
+		if err != nil {
+			return nil, err
+		}
+		c.FallbackEndpoints = append(c.FallbackEndpoints, e)
+	}
+
+	if l.Flow != 0 {
+		if err := c.Validate(l.Flow); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+func setString(dst *string, val *string) {
+	if val != nil {
+		*dst = *val
+	}
+}
+
+func endpointPreset(name string) (*Endpoint, error) {
+	e, ok := Endpoints[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown endpoint preset %q", name)
+	}
+	return e, nil
+}
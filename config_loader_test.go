@@ -0,0 +1,174 @@
+package vkoauth_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ciricc/vkoauth"
+	"github.com/ciricc/vkoauth/scope"
+)
+
+func envMap(m map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := m[key]
+		return v, ok
+	}
+}
+
+func writeConfigFile(t *testing.T, body string) string {
+	path := filepath.Join(t.TempDir(), "vk.json")
+	if err := os.WriteFile(path, []byte(body), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigLoaderEnv(t *testing.T) {
+	c, err := vkoauth.ConfigLoader{
+		EnvPrefix: "VK_",
+		Flow:      vkoauth.FlowCode,
+		LookupEnv: envMap(map[string]string{
+			"VK_CLIENT_ID":     "2274003",
+			"VK_CLIENT_SECRET": "secret",
+			"VK_REDIRECT_URI":  "https://example.com/callback",
+			"VK_SCOPE":         "wall,offline",
+			"VK_GROUP_SCOPE":   "manage",
+		}),
+	}.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.ClientId != "2274003" || c.ClientSecret != "secret" || c.RedirectUri != "https://example.com/callback" {
+		t.Errorf("unexpected config: %+v", c)
+	}
+	if c.Scope != scope.User.Wall|scope.User.Offline || c.GroupScope != scope.Group.Manage {
+		t.Errorf("unexpected scope: %d %d", c.Scope, c.GroupScope)
+	}
+	if c.Endpoint != nil || c.Version != "" {
+		t.Errorf("unexpected defaults: %+v", c)
+	}
+}
+
+func TestConfigLoaderFileAndPrecedence(t *testing.T) {
+	path := writeConfigFile(t, `{
+		"client_id": 2274003,
+		"client_secret": "file-secret",
+		"version": "5.130",
+		"redirect_uri": "https://example.com/callback",
+		"scope": ["friends", "photos"],
+		"endpoint": {"token_url": "https://oauth.vk.ru/access_token"}
+	}`)
+
+	c, err := vkoauth.ConfigLoader{
+		EnvPrefix: "APP_",
+		File:      path,
+		Flow:      vkoauth.FlowCode,
+		LookupEnv: envMap(map[string]string{
+			"APP_CLIENT_SECRET": "env-secret",
+			"APP_AUTH_URL":      "https://oauth.vk.ru/authorize",
+		}),
+	}.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.ClientId != "2274003" || c.ClientSecret != "env-secret" || c.Version != "5.130" {
+		t.Errorf("unexpected config: %+v", c)
+	}
+	if c.Scope != scope.User.Friends|scope.User.Photos {
+		t.Errorf("unexpected scope: %d", c.Scope)
+	}
+	if c.Endpoint.TokenUrl != "https://oauth.vk.ru/access_token" || c.Endpoint.AuthUrl != "https://oauth.vk.ru/authorize" ||
+		c.Endpoint.PasswordTokenUrl != vkoauth.DefaultVkEndpoint.PasswordTokenUrl {
+		t.Errorf("unexpected endpoint: %+v", c.Endpoint)
+	}
+}
+
+func TestConfigLoaderErrors(t *testing.T) {
+	_, err := vkoauth.ConfigLoader{
+		EnvPrefix: "VK_",
+		LookupEnv: envMap(map[string]string{"VK_SCOPE": "wall,wal"}),
+	}.Load()
+	unknown := &scope.UnknownNameError{}
+	if !errors.As(err, &unknown) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	_, err = vkoauth.ConfigLoader{
+		File:      writeConfigFile(t, `{"client_id": "1", "clientsecret": "typo"}`),
+		LookupEnv: envMap(nil),
+	}.Load()
+	if err == nil {
+		t.Errorf("expected error for unknown field")
+	}
+
+	_, err = vkoauth.ConfigLoader{
+		EnvPrefix: "VK_",
+		Flow:      vkoauth.FlowClientCredentials,
+		LookupEnv: envMap(map[string]string{"VK_CLIENT_ID": "1"}),
+	}.Load()
+	if fields := validationFields(err); len(fields) != 1 || fields[0] != "ClientSecret" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestConfigLoaderEndpointPreset(t *testing.T) {
+	c, err := vkoauth.ConfigLoader{
+		EnvPrefix: "VK_",
+		File:      writeConfigFile(t, `{"client_id": 1, "endpoint_preset": "vk.com", "fallback_endpoints": ["vk.com"]}`),
+		LookupEnv: envMap(map[string]string{
+			"VK_ENDPOINT":           "vk.ru",
+			"VK_TOKEN_URL":          "https://proxy.example.com/access_token",
+			"VK_FALLBACK_ENDPOINTS": "vk.com, ",
+		}),
+	}.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Endpoint.AuthUrl != vkoauth.VkRuEndpoint.AuthUrl || c.Endpoint.TokenUrl != "https://proxy.example.com/access_token" {
+		t.Errorf("unexpected endpoint: %+v", c.Endpoint)
+	}
+	if len(c.FallbackEndpoints) != 1 || c.FallbackEndpoints[0] != vkoauth.VkComEndpoint {
+		t.Errorf("unexpected fallback endpoints: %+v", c.FallbackEndpoints)
+	}
+
+	_, err = vkoauth.ConfigLoader{
+		EnvPrefix: "VK_",
+		LookupEnv: envMap(map[string]string{"VK_FALLBACK_ENDPOINTS": "vk.org"}),
+	}.Load()
+	if err == nil {
+		t.Errorf("expected error for unknown preset")
+	}
+}
+
+func TestConfigLoaderEnvEndpointOverridesFile(t *testing.T) {
+	path := writeConfigFile(t, `{"client_id": 1, "endpoint": {"auth_url": "https://file.example/authorize"}}`)
+
+	c, err := vkoauth.ConfigLoader{
+		EnvPrefix: "VK_",
+		File:      path,
+		LookupEnv: envMap(map[string]string{"VK_ENDPOINT": "vk.ru"}),
+	}.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *c.Endpoint != *vkoauth.VkRuEndpoint {
+		t.Errorf("unexpected endpoint: %+v", c.Endpoint)
+	}
+
+	c, err = vkoauth.ConfigLoader{
+		EnvPrefix: "VK_",
+		File:      path,
+		LookupEnv: envMap(nil),
+	}.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Endpoint.AuthUrl != "https://file.example/authorize" || c.Endpoint.TokenUrl != vkoauth.VkComEndpoint.TokenUrl {
+		t.Errorf("unexpected endpoint: %+v", c.Endpoint)
+	}
+}
@@ -1,8 +1,68 @@
 package display
 
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
 // Стилизация страницы авторизации
 type Display string
 
 const Page Display = "page"
 const Popup Display = "popup"
 const Mobile Display = "mobile"
+
+// Ошибка, которую возвращает Validate для неизвестного значения
+type InvalidError struct {
+	Value Display
+}
+
+func (e *InvalidError) Error() string {
+	return fmt.Sprintf("invalid display value: %q", string(e.Value))
+}
+
+// Возвращает true, если d - одно из значений Page, Popup или Mobile
+func (d Display) IsValid() bool {
+	return d == Page || d == Popup || d == Mobile
+}
+
+// Возвращает *InvalidError, если d не является одним из значений Page, Popup или Mobile
+func (d Display) Validate() error {
+	if !d.IsValid() {
+		return &InvalidError{Value: d}
+	}
+	return nil
+}
+
+// Подстроки User-Agent мобильных браузеров
+var mobileUserAgents = []string{"Mobi", "iPhone", "iPod", "Windows Phone", "Opera Mini"}
+
+// Выбирает стиль страницы авторизации по запросу пользователя
+// Для мобильных устройств возвращает Mobile, иначе desktop (Page или Popup, если страница открывается во всплывающем окне)
+// Сначала учитывается Client Hint Sec-CH-UA-Mobile, затем User-Agent
+func FromRequest(r *http.Request, desktop Display) Display {
+	if desktop != Popup {
+		desktop = Page
+	}
+
+	if r == nil {
+		return desktop
+	}
+
+	switch strings.TrimSpace(r.Header.Get("Sec-CH-UA-Mobile")) {
+	case "?1":
+		return Mobile
+	case "?0":
+		return desktop
+	}
+
+	ua := r.UserAgent()
+	for _, s := range mobileUserAgents {
+		if strings.Contains(ua, s) {
+			return Mobile
+		}
+	}
+
+	return desktop
+}
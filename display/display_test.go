@@ -0,0 +1,80 @@
+package display
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	for _, d := range []Display{Page, Popup, Mobile} {
+		if err := d.Validate(); err != nil {
+			t.Errorf("unexpected error for %q: %v", d, err)
+		}
+	}
+
+	for _, d := range []Display{"", "wap", "Page"} {
+		err := d.Validate()
+		invalid := &InvalidError{}
+		if !errors.As(err, &invalid) || invalid.Value != d {
+			t.Errorf("unexpected error for %q: %v", d, err)
+		}
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	cases := []struct {
+		UserAgent string
+		Mobile    string
+		Desktop   Display
+		Expected  Display
+	}{
+		{
+			UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 16_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.0 Mobile/15E148 Safari/604.1",
+			Expected:  Mobile,
+		},
+		{
+			UserAgent: "Mozilla/5.0 (Linux; Android 13; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/106.0.0.0 Mobile Safari/537.36",
+			Expected:  Mobile,
+		},
+		{
+			UserAgent: "Mozilla/5.0 (Linux; Android 13; Pixel Tablet) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/106.0.0.0 Safari/537.36",
+			Expected:  Page,
+		},
+		{
+			UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/106.0.0.0 Safari/537.36",
+			Desktop:   Popup,
+			Expected:  Popup,
+		},
+		{
+			UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64)",
+			Mobile:    "?1",
+			Expected:  Mobile,
+		},
+		{
+			UserAgent: "Mozilla/5.0 (Linux; Android 13; Pixel 7) Mobile",
+			Mobile:    "?0",
+			Expected:  Page,
+		},
+		{
+			Desktop:  "invalid",
+			Expected: Page,
+		},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("User-Agent", c.UserAgent)
+		if c.Mobile != "" {
+			r.Header.Set("Sec-CH-UA-Mobile", c.Mobile)
+		}
+
+		if d := FromRequest(r, c.Desktop); d != c.Expected {
+			t.Errorf("unexpected display for %q (%q): %q", c.UserAgent, c.Mobile, d)
+		}
+	}
+
+	if FromRequest(nil, "") != Page {
+		t.Errorf("unexpected display for nil request")
+	}
+}
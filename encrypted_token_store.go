@@ -0,0 +1,208 @@
+package vkoauth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Префикс зашифрованной записи, в нем закодирована версия формата
+const encryptedTokenPrefix = "vkenc1."
+
+// Ошибка расшифровки токена (неизвестный ключ, поврежденная или подмененная запись)
+var ErrTokenDecrypt = errors.New("token decrypt error")
+
+// Ключ шифрования AES (16, 24 или 32 байта) с идентификатором
+// Идентификатор сохраняется рядом с шифротекстом и позволяет читать записи после смены ключа
+type EncryptionKey struct {
+	Id  string
+	Key []byte
+}
+
+// Обертка над TokenStore, которая шифрует токены с помощью AES-GCM
+// В открытом виде во вложенном хранилище остаются только UserId и Expires,
+// чтобы работали List и Prune. Остальные поля токена хранятся в зашифрованном виде
+type EncryptedTokenStore struct {
+	store   TokenStore
+	primary string
+	aeads   map[string]cipher.AEAD
+}
+
+// Создает шифрующее хранилище поверх store
+// Новые записи шифруются ключом primary, ключи previous используются только для чтения старых записей
+func NewEncryptedTokenStore(store TokenStore, primary EncryptionKey, previous ...EncryptionKey) (*EncryptedTokenStore, error) {
+	s := &EncryptedTokenStore{
+		store:   store,
+		primary: primary.Id,
+		aeads:   make(map[string]cipher.AEAD, len(previous)+1),
+	}
+
+	for _, k := range append([]EncryptionKey{primary}, previous...) {
+		if k.Id == "" || strings.Contains(k.Id, ".") {
+			return nil, fmt.Errorf("invalid encryption key id: %q", k.Id)
+		}
+
+		if _, ok := s.aeads[k.Id]; ok {
+			return nil, fmt.Errorf("duplicate encryption key id: %q", k.Id)
+		}
+
+		block, err := aes.NewCipher(k.Key)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q: %w", k.Id, err)
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q: %w", k.Id, err)
+		}
+
+		s.aeads[k.Id] = aead
+	}
+
+	return s, nil
+}
+
+func (s *EncryptedTokenStore) Get(ctx context.Context, key TokenKey) (*Token, error) {
+	sealed, err := s.store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	_, token, err := s.open(key, sealed)
+	return token, err
+}
+
+func (s *EncryptedTokenStore) Put(ctx context.Context, key TokenKey, token *Token) error {
+	if token == nil {
+		return fmt.Errorf("token is nil")
+	}
+
+	sealed, err := s.seal(key, token)
+	if err != nil {
+		return err
+	}
+	return s.store.Put(ctx, key, sealed)
+}
+
+func (s *EncryptedTokenStore) Delete(ctx context.Context, key TokenKey) error {
+	return s.store.Delete(ctx, key)
+}
+
+func (s *EncryptedTokenStore) List(ctx context.Context) ([]TokenKey, error) {
+	return s.store.List(ctx)
+}
+
+func (s *EncryptedTokenStore) Prune(ctx context.Context, now time.Time) (int, error) {
+	return s.store.Prune(ctx, now)
+}
+
+// Перешифровывает основным ключом все записи, зашифрованные предыдущими ключами,
+// а также записи, сохраненные во вложенном хранилище без шифрования
+// Возвращает количество перезаписанных токенов
+func (s *EncryptedTokenStore) Reencrypt(ctx context.Context) (int, error) {
+	keys, err := s.store.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, key := range keys {
+		stored, err := s.store.Get(ctx, key)
+		if errors.Is(err, ErrTokenNotFound) {
+			continue
+		}
+		if err != nil {
+			return n, err
+		}
+
+		token := stored
+		if strings.HasPrefix(stored.AccessToken, encryptedTokenPrefix) {
+			var keyId string
+			keyId, token, err = s.open(key, stored)
+			if err != nil {
+				return n, err
+			}
+			if keyId == s.primary {
+				continue
+			}
+		}
+
+		if err := s.Put(ctx, key, token); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+// Шифрует токен основным ключом
+func (s *EncryptedTokenStore) seal(key TokenKey, token *Token) (*Token, error) {
+	plain, err := json.Marshal(token)
+	if err != nil {
+		return nil, err
+	}
+
+	aead := s.aeads[s.primary]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	ciphertext := aead.Seal(nonce, nonce, plain, []byte(key.String()))
+	sealed := &Token{
+		AccessToken: encryptedTokenPrefix + s.primary + "." + base64.RawURLEncoding.EncodeToString(ciphertext),
+		UserId:      token.UserId,
+	}
+
+	if token.Expires != nil {
+		e := *token.Expires
+		sealed.Expires = &e
+	}
+
+	return sealed, nil
+}
+
+// Расшифровывает токен, возвращает идентификатор ключа, которым он был зашифрован
+func (s *EncryptedTokenStore) open(key TokenKey, sealed *Token) (string, *Token, error) {
+	rest := strings.TrimPrefix(sealed.AccessToken, encryptedTokenPrefix)
+	if rest == sealed.AccessToken {
+		return "", nil, fmt.Errorf("%w: token %s is not encrypted", ErrTokenDecrypt, key)
+	}
+
+	keyId, data, ok := strings.Cut(rest, ".")
+	if !ok {
+		return "", nil, fmt.Errorf("%w: malformed record for token %s", ErrTokenDecrypt, key)
+	}
+
+	aead, ok := s.aeads[keyId]
+	if !ok {
+		return keyId, nil, fmt.Errorf("%w: unknown key id %q for token %s", ErrTokenDecrypt, keyId, key)
+	}
+
+	ciphertext, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil || len(ciphertext) < aead.NonceSize() {
+		return keyId, nil, fmt.Errorf("%w: malformed record for token %s", ErrTokenDecrypt, key)
+	}
+
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ciphertext, []byte(key.String()))
+	if err != nil {
+		return keyId, nil, fmt.Errorf("%w: %v", ErrTokenDecrypt, err)
+	}
+
+	token := &Token{}
+	if err := json.Unmarshal(plain, token); err != nil {
+		return keyId, nil, err
+	}
+
+	return keyId, token, nil
+}
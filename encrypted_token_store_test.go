@@ -0,0 +1,114 @@
+package vkoauth_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ciricc/vkoauth"
+)
+
+var (
+	oldKey = vkoauth.EncryptionKey{Id: "k1", Key: bytes.Repeat([]byte{1}, 32)}
+	newKey = vkoauth.EncryptionKey{Id: "k2", Key: bytes.Repeat([]byte{2}, 32)}
+)
+
+func TestEncryptedTokenStore(t *testing.T) {
+	store, err := vkoauth.NewEncryptedTokenStore(vkoauth.NewMemoryTokenStore(), oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testTokenStore(t, store)
+}
+
+func TestEncryptedTokenStoreHidesToken(t *testing.T) {
+	ctx := context.Background()
+	inner := vkoauth.NewMemoryTokenStore()
+	store, _ := vkoauth.NewEncryptedTokenStore(inner, oldKey)
+	key := vkoauth.TokenKey{ClientId: "1", UserId: 1}
+
+	store.Put(ctx, key, &vkoauth.Token{AccessToken: "secret-token", UserId: 1, Raw: map[string]interface{}{"access_token": "secret-token"}})
+
+	sealed, err := inner.Get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sealed.AccessToken, "secret-token") || sealed.Raw != nil {
+		t.Errorf("token is stored in plaintext: %+v", sealed)
+	}
+	if sealed.UserId != 1 {
+		t.Errorf("unexpected user id: %d", sealed.UserId)
+	}
+
+	// Запись не должна расшифровываться под другим ключом хранилища
+	inner.Put(ctx, vkoauth.TokenKey{ClientId: "1", UserId: 2}, sealed)
+	if _, err := store.Get(ctx, vkoauth.TokenKey{ClientId: "1", UserId: 2}); !errors.Is(err, vkoauth.ErrTokenDecrypt) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestEncryptedTokenStoreRotation(t *testing.T) {
+	ctx := context.Background()
+	inner := vkoauth.NewMemoryTokenStore()
+	key := vkoauth.TokenKey{ClientId: "1", UserId: 1}
+	plainKey := vkoauth.TokenKey{ClientId: "1", UserId: 2}
+
+	oldStore, _ := vkoauth.NewEncryptedTokenStore(inner, oldKey)
+	oldStore.Put(ctx, key, &vkoauth.Token{AccessToken: "token", UserId: 1})
+	inner.Put(ctx, plainKey, &vkoauth.Token{AccessToken: "plain", UserId: 2})
+
+	newOnly, _ := vkoauth.NewEncryptedTokenStore(inner, newKey)
+	if _, err := newOnly.Get(ctx, key); !errors.Is(err, vkoauth.ErrTokenDecrypt) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	store, err := vkoauth.NewEncryptedTokenStore(inner, newKey, oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "token" {
+		t.Errorf("unexpected token: %+v", token)
+	}
+
+	n, err := store.Reencrypt(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("unexpected reencrypted count: %d", n)
+	}
+
+	for _, k := range []vkoauth.TokenKey{key, plainKey} {
+		if _, err := newOnly.Get(ctx, k); err != nil {
+			t.Errorf("token %s is not reencrypted: %v", k, err)
+		}
+	}
+
+	n, _ = store.Reencrypt(ctx)
+	if n != 0 {
+		t.Errorf("unexpected reencrypted count: %d", n)
+	}
+}
+
+func TestEncryptedTokenStoreInvalidKeys(t *testing.T) {
+	inner := vkoauth.NewMemoryTokenStore()
+	cases := [][]vkoauth.EncryptionKey{
+		{{Id: "", Key: oldKey.Key}},
+		{{Id: "a.b", Key: oldKey.Key}},
+		{{Id: "k", Key: []byte("short")}},
+		{oldKey, oldKey},
+	}
+
+	for _, keys := range cases {
+		if _, err := vkoauth.NewEncryptedTokenStore(inner, keys[0], keys[1:]...); err == nil {
+			t.Errorf("expected error for keys %v", keys)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package vkoauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// Адреса авторизации на домене vk.com (используются по умолчанию)
+var VkComEndpoint = &Endpoint{
+	AuthUrl:          "https://oauth.vk.com/authorize",
+	PasswordTokenUrl: "https://oauth.vk.com/token",
+	TokenUrl:         "https://oauth.vk.com/access_token",
+	ApiUrl:           "https://api.vk.com/method",
+}
+
+// Адреса авторизации на домене vk.ru
+var VkRuEndpoint = &Endpoint{
+	AuthUrl:          "https://oauth.vk.ru/authorize",
+	PasswordTokenUrl: "https://oauth.vk.ru/token",
+	TokenUrl:         "https://oauth.vk.ru/access_token",
+	ApiUrl:           "https://api.vk.ru/method",
+}
+
+// Адреса авторизации VK ID (OAuth 2.1)
+// Ограничения:
+//   - авторизация по логину и паролю недоступна, PasswordTokenUrl не указан;
+//   - для Authorization Code Flow нужен PKCE: передайте code_challenge и code_challenge_method
+//     в CodeFlowAuthUrl, а grant_type=authorization_code, code_verifier и device_id в ExchangeCode
+//     с помощью SetUrlParam;
+//   - запросы не переключаются между VK ID и адресами oauth.vk.com/oauth.vk.ru (FallbackEndpoints)
+var VkIdEndpoint = &Endpoint{
+	AuthUrl:  "https://id.vk.com/authorize",
+	TokenUrl: "https://id.vk.com/oauth2/auth",
+	ApiUrl:   "https://api.vk.com/method",
+}
+
+// Именованные наборы адресов авторизации
+var Endpoints = map[string]*Endpoint{
+	"vk.com":    VkComEndpoint,
+	"vk.ru":     VkRuEndpoint,
+	"id.vk.com": VkIdEndpoint,
+}
+
+// Возвращает основной набор адресов и запасные наборы из FallbackEndpoints
+// Запасные наборы другого протокола (VK ID или oauth.vk.com) пропускаются
+func (v *Config) endpoints() []*Endpoint {
+	primary := v.endpoint()
+	endpoints := []*Endpoint{primary}
+	for _, e := range v.FallbackEndpoints {
+		if e != nil && isVkId(e) == isVkId(primary) {
+			endpoints = append(endpoints, e)
+		}
+	}
+	return endpoints
+}
+
+// Проверяет, что адреса авторизации относятся к VK ID
+func isVkId(e *Endpoint) bool {
+	u, err := url.Parse(e.AuthUrl)
+	return err == nil && u.Host == "id.vk.com"
+}
+
+func tokenUrl(e *Endpoint) string         { return e.TokenUrl }
+func passwordTokenUrl(e *Endpoint) string { return e.PasswordTokenUrl }
+
+// Проверяет, нужно ли повторить запрос на следующем наборе адресов:
+// при ошибке соединения или ответе сервера с кодом 5xx (в том числе не в формате JSON)
+func shouldFailover(ctx context.Context, err error) bool {
+	if err == nil || (ctx != nil && ctx.Err() != nil) {
+		return false
+	}
+
+	responseErr := &ResponseError{}
+	if errors.As(err, &responseErr) {
+		return responseErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	tokenErr := &TokenError{}
+	if errors.As(err, &tokenErr) {
+		return tokenErr.Response != nil && tokenErr.Response.StatusCode >= http.StatusInternalServerError
+	}
+
+	urlErr := &url.Error{}
+	return errors.As(err, &urlErr)
+}
@@ -0,0 +1,144 @@
+package vkoauth_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ciricc/vkoauth"
+)
+
+func tokenServer(t *testing.T, status int, body string, hits *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*hits++
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+}
+
+func TestEndpointFailover(t *testing.T) {
+	primaryHits, fallbackHits := 0, 0
+	primary := tokenServer(t, http.StatusBadGateway, `<html>502 Bad Gateway</html>`, &primaryHits)
+	defer primary.Close()
+	fallback := tokenServer(t, http.StatusOK, `{"access_token":"SERVICE_TOKEN"}`, &fallbackHits)
+	defer fallback.Close()
+
+	c := conf(primary.URL)
+	c.FallbackEndpoints = []*vkoauth.Endpoint{
+		{TokenUrl: ""},
+		{TokenUrl: fallback.URL + "/access_token"},
+	}
+
+	token, err := c.GetServiceToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "SERVICE_TOKEN" || primaryHits != 1 || fallbackHits != 1 {
+		t.Errorf("unexpected result: %+v, hits %d/%d", token, primaryHits, fallbackHits)
+	}
+}
+
+func TestEndpointFailoverConnectionError(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	hits := 0
+	fallback := tokenServer(t, http.StatusOK, `{"access_token":"SERVICE_TOKEN"}`, &hits)
+	defer fallback.Close()
+
+	c := conf(down.URL)
+	c.FallbackEndpoints = []*vkoauth.Endpoint{{TokenUrl: fallback.URL + "/access_token"}}
+
+	token, err := c.GetServiceToken(context.Background())
+	if err != nil || token.AccessToken != "SERVICE_TOKEN" {
+		t.Errorf("unexpected result: %+v %v", token, err)
+	}
+}
+
+func TestEndpointNoFailoverOnClientError(t *testing.T) {
+	primaryHits, fallbackHits := 0, 0
+	primary := tokenServer(t, http.StatusUnauthorized, `{"error":"invalid_client"}`, &primaryHits)
+	defer primary.Close()
+	fallback := tokenServer(t, http.StatusOK, `{"access_token":"SERVICE_TOKEN"}`, &fallbackHits)
+	defer fallback.Close()
+
+	c := conf(primary.URL)
+	c.FallbackEndpoints = []*vkoauth.Endpoint{{TokenUrl: fallback.URL + "/access_token"}}
+
+	_, err := c.GetServiceToken(context.Background())
+	tokenErr := &vkoauth.TokenError{}
+	if !errors.As(err, &tokenErr) || tokenErr.ErrorCode != "invalid_client" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if fallbackHits != 0 {
+		t.Errorf("fallback endpoint must not be requested")
+	}
+}
+
+func TestEndpointFailoverLastError(t *testing.T) {
+	hits := 0
+	primary := tokenServer(t, http.StatusServiceUnavailable, ``, &hits)
+	defer primary.Close()
+	fallback := tokenServer(t, http.StatusBadGateway, ``, &hits)
+	defer fallback.Close()
+
+	c := conf(primary.URL)
+	c.FallbackEndpoints = []*vkoauth.Endpoint{{TokenUrl: fallback.URL + "/access_token"}}
+
+	_, err := c.GetServiceToken(context.Background())
+	responseErr := &vkoauth.ResponseError{}
+	if !errors.As(err, &responseErr) || responseErr.StatusCode != http.StatusBadGateway || hits != 2 {
+		t.Errorf("unexpected error: %v, hits %d", err, hits)
+	}
+}
+
+func TestEndpointPresets(t *testing.T) {
+	if vkoauth.DefaultVkEndpoint != vkoauth.Endpoints["vk.com"] {
+		t.Errorf("default endpoint must be vk.com preset")
+	}
+	if vkoauth.Endpoints["id.vk.com"] != vkoauth.VkIdEndpoint || vkoauth.VkIdEndpoint.PasswordTokenUrl != "" {
+		t.Errorf("unexpected VK ID endpoint: %+v", vkoauth.Endpoints["id.vk.com"])
+	}
+	if vkoauth.Endpoints["vk.ru"].TokenUrl != "https://oauth.vk.ru/access_token" {
+		t.Errorf("unexpected vk.ru endpoint: %+v", vkoauth.Endpoints["vk.ru"])
+	}
+}
+
+func TestEndpointFailoverNilContext(t *testing.T) {
+	hits := 0
+	primary := tokenServer(t, http.StatusBadGateway, `{}`, &hits)
+	defer primary.Close()
+	fallback := tokenServer(t, http.StatusOK, `{"access_token":"SERVICE_TOKEN"}`, &hits)
+	defer fallback.Close()
+
+	c := conf(primary.URL)
+	c.FallbackEndpoints = []*vkoauth.Endpoint{{TokenUrl: fallback.URL + "/access_token"}}
+
+	token, err := c.GetServiceToken(nil)
+	if err != nil || token.AccessToken != "SERVICE_TOKEN" || hits != 2 {
+		t.Errorf("unexpected result: %+v %v, hits %d", token, err, hits)
+	}
+}
+
+func TestEndpointNoFailoverToVkId(t *testing.T) {
+	primaryHits, fallbackHits := 0, 0
+	primary := tokenServer(t, http.StatusBadGateway, `{}`, &primaryHits)
+	defer primary.Close()
+	fallback := tokenServer(t, http.StatusOK, `{"access_token":"SERVICE_TOKEN"}`, &fallbackHits)
+	defer fallback.Close()
+
+	c := conf(primary.URL)
+	c.FallbackEndpoints = []*vkoauth.Endpoint{{
+		AuthUrl:  vkoauth.VkIdEndpoint.AuthUrl,
+		TokenUrl: fallback.URL + "/access_token",
+	}}
+
+	if _, err := c.GetServiceToken(context.Background()); err == nil {
+		t.Errorf("expected error")
+	}
+	if primaryHits != 1 || fallbackHits != 0 {
+		t.Errorf("VK ID endpoint must not be used as fallback, hits %d/%d", primaryHits, fallbackHits)
+	}
+}
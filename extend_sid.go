@@ -28,7 +28,7 @@ func (v *Config) ExtendSid(ctx context.Context, p SidParams, opts ...AuthOption)
 	}
 
 	tokenOpts = append(tokenOpts, opts...)
-	return v.doTokenRequest(ctx, v.buildTokenUrl(v.endpoint().PasswordTokenUrl,
+	return v.tokenRequest(ctx, passwordTokenUrl,
 		tokenOpts...,
-	))
+	)
 }
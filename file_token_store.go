@@ -0,0 +1,220 @@
+package vkoauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Текущая версия формата файла FileTokenStore
+const fileTokenStoreVersion = 1
+
+type fileTokenStoreJson struct {
+	Version int                   `json:"version"`
+	Tokens  []fileTokenRecordJson `json:"tokens"`
+}
+
+type fileTokenRecordJson struct {
+	ClientId string `json:"client_id"`
+	UserId   int64  `json:"user_id"`
+	GroupId  int64  `json:"group_id"`
+	Token    *Token `json:"token"`
+}
+
+// Хранилище токенов в JSON файле
+// Файл перезаписывается атомарно (через временный файл и переименование) с правами 0600
+// Безопасно для конкурентного использования в пределах одного процесса
+type FileTokenStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// Создает хранилище токенов в файле path
+// Файл будет создан при первой записи
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+func (s *FileTokenStore) Get(ctx context.Context, key TokenKey) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	token, ok := tokens[key]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	return token, nil
+}
+
+func (s *FileTokenStore) Put(ctx context.Context, key TokenKey, token *Token) error {
+	if token == nil {
+		return fmt.Errorf("token is nil")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	tokens[key] = token
+	return s.save(tokens)
+}
+
+func (s *FileTokenStore) Delete(ctx context.Context, key TokenKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := tokens[key]; !ok {
+		return nil
+	}
+
+	delete(tokens, key)
+	return s.save(tokens)
+}
+
+func (s *FileTokenStore) List(ctx context.Context) ([]TokenKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]TokenKey, 0, len(tokens))
+	for k := range tokens {
+		keys = append(keys, k)
+	}
+	sortTokenKeys(keys)
+	return keys, nil
+}
+
+func (s *FileTokenStore) Prune(ctx context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.load()
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for k, token := range tokens {
+		if tokenExpired(token, now) {
+			delete(tokens, k)
+			n++
+		}
+	}
+
+	if n == 0 {
+		return 0, nil
+	}
+	return n, s.save(tokens)
+}
+
+// Читает токены из файла, отсутствующий файл считается пустым хранилищем
+func (s *FileTokenStore) load() (map[TokenKey]*Token, error) {
+	tokens := make(map[TokenKey]*Token)
+
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := fileTokenStoreJson{}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, fmt.Errorf("parse token store file error: %w", err)
+	}
+
+	if data.Version != fileTokenStoreVersion {
+		return nil, fmt.Errorf("unsupported token store file version: %d", data.Version)
+	}
+
+	for _, r := range data.Tokens {
+		if r.Token == nil {
+			continue
+		}
+		tokens[TokenKey{ClientId: r.ClientId, UserId: r.UserId, GroupId: r.GroupId}] = r.Token
+	}
+
+	return tokens, nil
+}
+
+// Атомарно записывает токены в файл
+func (s *FileTokenStore) save(tokens map[TokenKey]*Token) error {
+	keys := make([]TokenKey, 0, len(tokens))
+	for k := range tokens {
+		keys = append(keys, k)
+	}
+	sortTokenKeys(keys)
+
+	data := fileTokenStoreJson{
+		Version: fileTokenStoreVersion,
+		Tokens:  make([]fileTokenRecordJson, len(keys)),
+	}
+
+	for i, k := range keys {
+		data.Tokens[i] = fileTokenRecordJson{
+			ClientId: k.ClientId,
+			UserId:   k.UserId,
+			GroupId:  k.GroupId,
+			Token:    tokens[k],
+		}
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), "."+filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, s.path)
+}
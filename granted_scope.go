@@ -0,0 +1,30 @@
+package vkoauth
+
+import (
+	"context"
+
+	"github.com/ciricc/vkoauth/scope"
+)
+
+// Возвращает права доступа, фактически выданные токену пользователя (метод account.getAppPermissions)
+// Пользователь может снять часть прав на странице авторизации, поэтому они могут отличаться от Config.Scope
+// Смотрите документацию: https://dev.vk.com/method/account.getAppPermissions
+func (v *Config) GrantedScope(ctx context.Context, accessToken string) (scope.Scope, error) {
+	var granted scope.Scope
+	if err := v.CallMethod(ctx, StaticTokenSource(accessToken), "account.getAppPermissions", nil, &granted); err != nil {
+		return 0, err
+	}
+
+	return granted, nil
+}
+
+// Возвращает права из Config.Scope, которые не были выданы токену пользователя
+// Если результат не равен 0, пользователя нужно направить на повторную авторизацию (например, с AuthParams.Revoke)
+func (v *Config) MissingScope(ctx context.Context, accessToken string) (scope.Scope, error) {
+	granted, err := v.GrantedScope(ctx, accessToken)
+	if err != nil {
+		return 0, err
+	}
+
+	return v.Scope.Missing(granted), nil
+}
@@ -0,0 +1,53 @@
+package vkoauth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ciricc/vkoauth"
+	"github.com/ciricc/vkoauth/scope"
+)
+
+func TestGrantedScope(t *testing.T) {
+	serv := apiServer(t, map[string]string{
+		"/method/account.getAppPermissions": `{"response":8192}`,
+	})
+	defer serv.Close()
+
+	c := apiConf(serv.URL)
+	granted, err := c.GrantedScope(context.Background(), "USER_TOKEN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if granted != scope.User.Wall {
+		t.Errorf("unexpected granted scope: %d", granted)
+	}
+
+	missing, err := c.MissingScope(context.Background(), "USER_TOKEN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missing != scope.User.Notify {
+		t.Errorf("unexpected missing scope: %d", missing)
+	}
+}
+
+func TestGrantedScopeError(t *testing.T) {
+	serv := apiServer(t, map[string]string{
+		"/method/account.getAppPermissions": `{"error":{"error_code":5,"error_msg":"User authorization failed: invalid access_token (4)."}}`,
+	})
+	defer serv.Close()
+
+	c := apiConf(serv.URL)
+	_, err := c.MissingScope(context.Background(), "USER_TOKEN")
+	apiErr := &vkoauth.ApiError{}
+	if !errors.As(err, &apiErr) || apiErr.Code != 5 {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	invalidErr := &vkoauth.InvalidTokenError{}
+	if !errors.As(err, &invalidErr) || invalidErr.Token.AccessToken != "USER_TOKEN" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -13,12 +13,14 @@ import (
 type AuthParams struct {
 	State    string          // Произвольная строка, будет возвращена вместе с редиректом. Используется для защиты от CSRF атак
 	Revoke   bool            // Обязательное подтверждение выдачи прав, даже если приложению уже были предоставлены права ранее
-	GroupIds []int64         // Идентификаторы сообществ, токены которых нужно получить
+	GroupIds []int64         // Идентификаторы сообществ, токены которых нужно получить (права берутся из Config.GroupScope)
 	Display  display.Display // Стиль отображения страницы авторизации
+	Lang     string          // Язык страницы авторизации и описаний ошибок (LangRu, LangEn и т.д.)
 }
 
 // Создает URL, на который нужно направить пользователя для проведений авторизации методом Implicit Flow (клиентское приложение, не сервер)
-func (v *Config) ImplicitFlowAuthUrl(params AuthParams, opts ...AuthOption) string {
+// Возвращает ошибку, если параметры авторизации некорректны (например, ErrMixedScope)
+func (v *Config) ImplicitFlowAuthUrl(params AuthParams, opts ...AuthOption) (string, error) {
 	return v.buildAuthUrl(params, "token", opts...)
 }
 
@@ -0,0 +1,65 @@
+package vkoauth
+
+// Языки, для которых есть сообщения в ErrorMessages
+const (
+	LangRu = "ru"
+	LangEn = "en"
+)
+
+// Язык сообщений, если для запрошенного языка сообщение не найдено
+var DefaultLang = LangRu
+
+// Тексты ошибок для пользователя по языкам и кодам ошибок (TokenError.ErrorCode и TokenError.ErrorType)
+// Сообщение с пустым кодом используется для неизвестных ошибок
+// Можно дополнить своими языками и кодами до начала использования библиотеки
+var ErrorMessages = map[string]map[string]string{
+	LangRu: {
+		"":                                  "Не удалось выполнить авторизацию. Попробуйте еще раз",
+		"invalid_request":                   "Некорректный запрос авторизации",
+		"invalid_client":                    "Приложение не найдено или указан неверный секретный ключ",
+		"invalid_grant":                     "Код авторизации недействителен или устарел",
+		"invalid_scope":                     "Запрошены недопустимые права доступа",
+		"unauthorized_client":               "Приложению запрещен этот способ авторизации",
+		"unsupported_grant_type":            "Способ авторизации не поддерживается",
+		"access_denied":                     "Вы отказались предоставить доступ приложению",
+		"need_validation":                   "Требуется подтверждение входа",
+		"need_captcha":                      "Введите код с картинки",
+		"server_error":                      "Ошибка на стороне ВКонтакте. Попробуйте позже",
+		"temporarily_unavailable":           "Сервис временно недоступен. Попробуйте позже",
+		"username_or_password_is_incorrect": "Неверный логин или пароль",
+		"wrong_otp":                         "Неверный код подтверждения",
+		"otp_format_is_incorrect":           "Код подтверждения должен состоять из цифр",
+		"password_bruteforce_attempt":       "Слишком много попыток входа. Попробуйте позже",
+		ErrorCodeEmptyToken:                 "ВКонтакте не выдал ключ доступа. Попробуйте еще раз",
+	},
+	LangEn: {
+		"":                                  "Authorization failed. Please try again",
+		"invalid_request":                   "Invalid authorization request",
+		"invalid_client":                    "The application was not found or its secret key is invalid",
+		"invalid_grant":                     "The authorization code is invalid or expired",
+		"invalid_scope":                     "Invalid access permissions requested",
+		"unauthorized_client":               "The application is not allowed to use this authorization method",
+		"unsupported_grant_type":            "The authorization method is not supported",
+		"access_denied":                     "You declined to grant access to the application",
+		"need_validation":                   "Sign-in confirmation required",
+		"need_captcha":                      "Enter the code from the image",
+		"server_error":                      "VK server error. Please try again later",
+		"temporarily_unavailable":           "The service is temporarily unavailable. Please try again later",
+		"username_or_password_is_incorrect": "Incorrect login or password",
+		"wrong_otp":                         "Incorrect confirmation code",
+		"otp_format_is_incorrect":           "The confirmation code must contain only digits",
+		"password_bruteforce_attempt":       "Too many sign-in attempts. Please try again later",
+		ErrorCodeEmptyToken:                 "VK did not issue an access token. Please try again",
+	},
+}
+
+// Возвращает текст ошибки code на языке lang
+// Если для lang нет сообщения, ищет сообщение на языке DefaultLang
+func ErrorMessage(lang string, code string) (string, bool) {
+	for _, l := range []string{lang, DefaultLang} {
+		if msg, ok := ErrorMessages[l][code]; ok {
+			return msg, true
+		}
+	}
+	return "", false
+}
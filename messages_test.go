@@ -0,0 +1,111 @@
+package vkoauth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ciricc/vkoauth"
+)
+
+func TestTokenErrorMessage(t *testing.T) {
+	tests := []struct {
+		err  vkoauth.TokenError
+		lang string
+		msg  string
+	}{
+		{vkoauth.TokenError{ErrorCode: "invalid_client"}, vkoauth.LangEn, "The application was not found or its secret key is invalid"},
+		{vkoauth.TokenError{ErrorCode: "invalid_client"}, vkoauth.LangRu, "Приложение не найдено или указан неверный секретный ключ"},
+		{vkoauth.TokenError{ErrorCode: "invalid_client", ErrorType: "username_or_password_is_incorrect"}, vkoauth.LangEn, "Incorrect login or password"},
+		{vkoauth.TokenError{ErrorCode: "invalid_client"}, "uk", "Приложение не найдено или указан неверный секретный ключ"},
+		{vkoauth.TokenError{ErrorCode: "unknown_code"}, vkoauth.LangEn, "Authorization failed. Please try again"},
+	}
+
+	for _, test := range tests {
+		if msg := test.err.Message(test.lang); msg != test.msg {
+			t.Errorf("%s/%s: expected %q, got %q", test.err.ErrorCode, test.lang, test.msg, msg)
+		}
+	}
+}
+
+func TestTokenErrorDescription(t *testing.T) {
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("lang") != "en" {
+			t.Errorf("unexpected lang: %q", r.PostForm.Get("lang"))
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"unknown_code","error_description":"Something went wrong"}`))
+	}))
+	defer serv.Close()
+
+	c := conf(serv.URL)
+	_, err := c.GetServiceToken(context.Background(), vkoauth.Lang(vkoauth.LangEn))
+	tokenErr, ok := err.(*vkoauth.TokenError)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if tokenErr.Description() != "Something went wrong" {
+		t.Errorf("unexpected description: %q", tokenErr.Description())
+	}
+	// Для неизвестного кода используется описание от ВКонтакте
+	if tokenErr.Message(vkoauth.LangRu) != "Something went wrong" {
+		t.Errorf("unexpected message: %q", tokenErr.Message(vkoauth.LangRu))
+	}
+}
+
+func TestTokenErrorMessagePrefersDescriptionInLang(t *testing.T) {
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"invalid_client","error_description":"client_secret is incorrect"}`))
+	}))
+	defer serv.Close()
+
+	c := conf(serv.URL)
+	_, err := c.GetServiceToken(context.Background(), vkoauth.Lang(vkoauth.LangEn))
+	tokenErr, ok := err.(*vkoauth.TokenError)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if tokenErr.Lang != vkoauth.LangEn {
+		t.Errorf("unexpected lang: %q", tokenErr.Lang)
+	}
+	if tokenErr.Message(vkoauth.LangEn) != "client_secret is incorrect" {
+		t.Errorf("unexpected message: %q", tokenErr.Message(vkoauth.LangEn))
+	}
+	if tokenErr.Message(vkoauth.LangRu) != "Приложение не найдено или указан неверный секретный ключ" {
+		t.Errorf("unexpected message: %q", tokenErr.Message(vkoauth.LangRu))
+	}
+}
+
+func TestAuthUrlLang(t *testing.T) {
+	c := conf("http://localhost")
+	u, err := c.CodeFlowAuthUrl(vkoauth.AuthParams{Lang: vkoauth.LangEn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(u, "&lang=en&") {
+		t.Errorf("unexpected url: %q", u)
+	}
+}
+
+func TestRedirectErrorLang(t *testing.T) {
+	c := conf("")
+	_, err := c.ResultCode(url.Values{
+		"error":             {"access_denied"},
+		"error_description": {"User denied your request"},
+		"lang":              {"en"},
+	})
+	tokenErr, ok := err.(*vkoauth.TokenError)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tokenErr.Lang != vkoauth.LangEn || tokenErr.Message(vkoauth.LangEn) != "User denied your request" {
+		t.Errorf("unexpected error: %+v", tokenErr)
+	}
+}
@@ -47,7 +47,7 @@ func (v *Config) PasswordCredentials(ctx context.Context, p TokenParams, opts ..
 	}
 
 	tokenOpts = append(tokenOpts, opts...)
-	return v.doTokenRequest(ctx, v.buildTokenUrl(v.endpoint().PasswordTokenUrl,
+	return v.tokenRequest(ctx, passwordTokenUrl,
 		tokenOpts...,
-	))
+	)
 }
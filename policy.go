@@ -0,0 +1,149 @@
+package vkoauth
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/ciricc/vkoauth/scope"
+)
+
+// Тип приложения ВКонтакте
+type AppType int
+
+const AppStandalone AppType = 1 // Standalone приложение
+const AppWebsite AppType = 2    // Сайт
+const AppMini AppType = 3       // Мини приложение (VK Mini Apps)
+const AppService AppType = 4    // Сервисное приложение (только сервисный ключ)
+
+func (a AppType) String() string {
+	switch a {
+	case AppStandalone:
+		return "standalone"
+	case AppWebsite:
+		return "website"
+	case AppMini:
+		return "mini app"
+	case AppService:
+		return "service"
+	}
+	return fmt.Sprintf("AppType(%d)", int(a))
+}
+
+// Способ получения токена
+type Flow int
+
+const FlowImplicit Flow = 1          // Implicit Flow (ImplicitFlowAuthUrl, ResultToken)
+const FlowCode Flow = 2              // Authorization Code Flow (CodeFlowAuthUrl, ExchangeCode)
+const FlowPassword Flow = 3          // Прямая авторизация (PasswordCredentials)
+const FlowClientCredentials Flow = 4 // Сервисный ключ (GetServiceToken)
+const FlowExtendSid Flow = 5         // Продление сессии (ExtendSid)
+
+func (f Flow) String() string {
+	switch f {
+	case FlowImplicit:
+		return "implicit"
+	case FlowCode:
+		return "code"
+	case FlowPassword:
+		return "password"
+	case FlowClientCredentials:
+		return "client credentials"
+	case FlowExtendSid:
+		return "extend sid"
+	}
+	return fmt.Sprintf("Flow(%d)", int(f))
+}
+
+// Нарушение правил выдачи прав для типа приложения и способа авторизации
+type PolicyViolation struct {
+	Permission *scope.Permission // Право, к которому относится нарушение (nil, если нарушение не связано с конкретным правом)
+	Message    string
+}
+
+func (v PolicyViolation) Error() string {
+	return v.Message
+}
+
+// Какие способы авторизации доступны типам приложений
+var appFlows = map[AppType][]Flow{
+	AppStandalone: {FlowImplicit, FlowCode, FlowPassword, FlowClientCredentials, FlowExtendSid},
+	AppWebsite:    {FlowImplicit, FlowCode, FlowClientCredentials},
+	AppMini:       {FlowClientCredentials},
+	AppService:    {FlowClientCredentials},
+}
+
+// Проверяет, что права Config.Scope и Config.GroupScope могут быть выданы приложению типа app
+// при авторизации способом flow, и возвращает список нарушений
+// Предупреждения о правах (scope.Scope.Warnings для Config.Version) тоже возвращаются как нарушения
+// Используйте перед созданием URL авторизации, чтобы не узнавать о проблемах от пользователей
+func (v *Config) CheckPolicy(app AppType, flow Flow) []PolicyViolation {
+	violations := []PolicyViolation{}
+
+	flows, ok := appFlows[app]
+	if !ok {
+		return append(violations, PolicyViolation{Message: fmt.Sprintf("unknown app type: %s", app)})
+	}
+
+	if !containsFlow(flows, flow) {
+		violations = append(violations, PolicyViolation{
+			Message: fmt.Sprintf("%s flow is not available for %s apps", flow, app),
+		})
+	}
+
+	if flow == FlowClientCredentials {
+		if v.Scope != 0 || v.GroupScope != 0 {
+			violations = append(violations, PolicyViolation{
+				Message: "scope is not applicable to service tokens",
+			})
+		}
+		return violations
+	}
+
+	if v.GroupScope != 0 && flow != FlowImplicit && flow != FlowCode {
+		violations = append(violations, PolicyViolation{
+			Message: fmt.Sprintf("community tokens can not be obtained with %s flow", flow),
+		})
+	}
+
+	secureRedirect := false
+	if u, err := url.Parse(v.RedirectUri); err == nil && u.Scheme == "https" {
+		secureRedirect = true
+	}
+
+	for _, p := range v.Scope.Permissions() {
+		p := p
+		if p.Standalone && app != AppStandalone {
+			violations = append(violations, PolicyViolation{
+				Permission: &p,
+				Message:    fmt.Sprintf("permission %s is available only for standalone apps", p.Name),
+			})
+			continue
+		}
+
+		if p.Secure && app != AppStandalone && !secureRedirect {
+			violations = append(violations, PolicyViolation{
+				Permission: &p,
+				Message:    fmt.Sprintf("permission %s requires https redirect uri for %s apps", p.Name, app),
+			})
+		}
+	}
+
+	warnings := append(v.Scope.Warnings(v.version()), v.GroupScope.Warnings(v.version())...)
+	for _, w := range warnings {
+		violations = append(violations, PolicyViolation{
+			Permission: w.Permission,
+			Message:    w.Message,
+		})
+	}
+
+	return violations
+}
+
+func containsFlow(flows []Flow, flow Flow) bool {
+	for _, f := range flows {
+		if f == flow {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package vkoauth_test
+
+import (
+	"testing"
+
+	"github.com/ciricc/vkoauth"
+	"github.com/ciricc/vkoauth/scope"
+)
+
+func TestCheckPolicy(t *testing.T) {
+	cases := []struct {
+		Name        string
+		Config      vkoauth.Config
+		App         vkoauth.AppType
+		Flow        vkoauth.Flow
+		Permissions []string // Права нарушений, "" для нарушений без права
+	}{
+		{
+			Name:   "standalone messages",
+			Config: vkoauth.Config{Scope: scope.User.Messages | scope.User.Offline, RedirectUri: "https://oauth.vk.com/blank.html"},
+			App:    vkoauth.AppStandalone,
+			Flow:   vkoauth.FlowImplicit,
+		},
+		{
+			Name:        "website messages",
+			Config:      vkoauth.Config{Scope: scope.User.Messages | scope.User.Wall, RedirectUri: "https://example.com/callback"},
+			App:         vkoauth.AppWebsite,
+			Flow:        vkoauth.FlowCode,
+			Permissions: []string{"messages"},
+		},
+		{
+			Name:        "website offline over http",
+			Config:      vkoauth.Config{Scope: scope.User.Offline | scope.User.Notify | scope.User.Friends, RedirectUri: "http://example.com/callback"},
+			App:         vkoauth.AppWebsite,
+			Flow:        vkoauth.FlowCode,
+			Permissions: []string{"notify", "offline"},
+		},
+		{
+			Name:   "website offline over https",
+			Config: vkoauth.Config{Scope: scope.User.Offline, RedirectUri: "https://example.com/callback"},
+			App:    vkoauth.AppWebsite,
+			Flow:   vkoauth.FlowCode,
+		},
+		{
+			Name:        "website password",
+			Config:      vkoauth.Config{Scope: scope.User.Wall},
+			App:         vkoauth.AppWebsite,
+			Flow:        vkoauth.FlowPassword,
+			Permissions: []string{""},
+		},
+		{
+			Name:        "service with scope",
+			Config:      vkoauth.Config{Scope: scope.User.Wall},
+			App:         vkoauth.AppService,
+			Flow:        vkoauth.FlowClientCredentials,
+			Permissions: []string{""},
+		},
+		{
+			Name:        "mini app implicit",
+			Config:      vkoauth.Config{},
+			App:         vkoauth.AppMini,
+			Flow:        vkoauth.FlowImplicit,
+			Permissions: []string{""},
+		},
+		{
+			Name:        "group scope with password",
+			Config:      vkoauth.Config{GroupScope: scope.Group.Manage},
+			App:         vkoauth.AppStandalone,
+			Flow:        vkoauth.FlowPassword,
+			Permissions: []string{""},
+		},
+		{
+			Name:        "deprecated audio",
+			Config:      vkoauth.Config{Scope: scope.User.Audio | scope.User.Wall | 1<<5},
+			App:         vkoauth.AppStandalone,
+			Flow:        vkoauth.FlowImplicit,
+			Permissions: []string{"audio", ""},
+		},
+		{
+			Name:   "audio before deprecation",
+			Config: vkoauth.Config{Scope: scope.User.Audio, Version: "5.60"},
+			App:    vkoauth.AppStandalone,
+			Flow:   vkoauth.FlowImplicit,
+		},
+		{
+			Name:        "unknown app type",
+			Config:      vkoauth.Config{},
+			Flow:        vkoauth.FlowImplicit,
+			Permissions: []string{""},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			violations := c.Config.CheckPolicy(c.App, c.Flow)
+			if len(violations) != len(c.Permissions) {
+				t.Fatalf("unexpected violations: %v", violations)
+			}
+
+			for i, v := range violations {
+				name := ""
+				if v.Permission != nil {
+					name = v.Permission.Name
+				}
+				if name != c.Permissions[i] {
+					t.Errorf("unexpected violation: %v", v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package vkoauth
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+// Ошибка, которую возвращает Registry, если приложение не найдено
+var ErrAppNotFound = errors.New("app not found")
+
+// Ошибка, которую возвращает Registry.ByRedirectUri, если redirect_uri подходит нескольким приложениям
+// (например, Standalone приложениям с общим https://oauth.vk.com/blank.html)
+var ErrAmbiguousApp = errors.New("several apps match")
+
+// Реестр нескольких приложений ВКонтакте (сайт, Standalone, мини приложение и т.д.)
+// Позволяет найти Config по имени, идентификатору приложения или redirect_uri
+// Безопасен для конкурентного использования
+type Registry struct {
+	mu   sync.RWMutex
+	apps map[string]*Config
+}
+
+// Создает пустой реестр приложений
+func NewRegistry() *Registry {
+	return &Registry{apps: make(map[string]*Config)}
+}
+
+// Добавляет приложение под именем name
+// Имена и идентификаторы приложений в реестре должны быть уникальными, идентификатор обязателен
+func (r *Registry) Register(name string, c *Config) error {
+	if name == "" {
+		return fmt.Errorf("app name is empty")
+	}
+	if c == nil {
+		return fmt.Errorf("config of app %q is nil", name)
+	}
+	if c.ClientId == "" {
+		return fmt.Errorf("client id of app %q is empty", name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.apps == nil {
+		r.apps = make(map[string]*Config)
+	}
+
+	if _, ok := r.apps[name]; ok {
+		return fmt.Errorf("app %q is already registered", name)
+	}
+
+	for n, app := range r.apps {
+		if app.ClientId == c.ClientId {
+			return fmt.Errorf("client id %s of app %q is already registered as %q", c.ClientId, name, n)
+		}
+	}
+
+	r.apps[name] = c
+	return nil
+}
+
+// Возвращает приложение по имени
+func (r *Registry) Get(name string) (*Config, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.apps[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrAppNotFound, name)
+	}
+	return c, nil
+}
+
+// Возвращает имя и конфигурацию приложения по идентификатору приложения
+func (r *Registry) ByClientId(clientId string) (string, *Config, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for name, c := range r.apps {
+		if c.ClientId == clientId {
+			return name, c, nil
+		}
+	}
+	return "", nil, fmt.Errorf("%w: client id %s", ErrAppNotFound, clientId)
+}
+
+// Возвращает имя и конфигурацию приложения, на redirect_uri которого пришел пользователь
+// requestUrl - полный URL входящего запроса, параметры запроса и фрагмент не учитываются
+// Возвращает ErrAmbiguousApp, если redirect_uri подходит нескольким приложениям
+func (r *Registry) ByRedirectUri(requestUrl string) (string, *Config, error) {
+	u, err := url.Parse(requestUrl)
+	if err != nil {
+		return "", nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matches := []string{}
+	for _, name := range r.sortedNames() {
+		c := r.apps[name]
+		redirect, err := url.Parse(c.RedirectUri)
+		if err != nil || c.RedirectUri == "" {
+			continue
+		}
+
+		if redirect.Scheme == u.Scheme && redirect.Host == u.Host && redirect.Path == u.Path {
+			matches = append(matches, name)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", nil, fmt.Errorf("%w: redirect uri %s", ErrAppNotFound, requestUrl)
+	case 1:
+		return matches[0], r.apps[matches[0]], nil
+	}
+	return "", nil, fmt.Errorf("%w: redirect uri %s matches apps %q", ErrAmbiguousApp, requestUrl, matches)
+}
+
+// Возвращает имена всех приложений в алфавитном порядке
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.sortedNames()
+}
+
+func (r *Registry) sortedNames() []string {
+	names := make([]string, 0, len(r.apps))
+	for name := range r.apps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
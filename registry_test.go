@@ -0,0 +1,96 @@
+package vkoauth_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ciricc/vkoauth"
+)
+
+func testRegistry(t *testing.T) *vkoauth.Registry {
+	r := vkoauth.NewRegistry()
+	apps := map[string]*vkoauth.Config{
+		"website":    {ClientId: "1", RedirectUri: "https://example.com/vk/callback"},
+		"standalone": {ClientId: "2", RedirectUri: "https://oauth.vk.com/blank.html"},
+		"mini":       {ClientId: "3"},
+	}
+	for name, c := range apps {
+		if err := r.Register(name, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r
+}
+
+func TestRegistry(t *testing.T) {
+	r := testRegistry(t)
+
+	if !reflect.DeepEqual(r.Names(), []string{"mini", "standalone", "website"}) {
+		t.Errorf("unexpected names: %v", r.Names())
+	}
+
+	c, err := r.Get("standalone")
+	if err != nil || c.ClientId != "2" {
+		t.Errorf("unexpected result: %+v %v", c, err)
+	}
+
+	name, c, err := r.ByClientId("3")
+	if err != nil || name != "mini" || c.ClientId != "3" {
+		t.Errorf("unexpected result: %q %+v %v", name, c, err)
+	}
+
+	name, _, err = r.ByRedirectUri("https://example.com/vk/callback?code=abc&state=123")
+	if err != nil || name != "website" {
+		t.Errorf("unexpected result: %q %v", name, err)
+	}
+
+	if _, err := r.Get("unknown"); !errors.Is(err, vkoauth.ErrAppNotFound) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, _, err := r.ByClientId("4"); !errors.Is(err, vkoauth.ErrAppNotFound) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, _, err := r.ByRedirectUri("https://example.com/other"); !errors.Is(err, vkoauth.ErrAppNotFound) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRegistryDuplicates(t *testing.T) {
+	r := testRegistry(t)
+
+	if err := r.Register("website", &vkoauth.Config{ClientId: "10"}); err == nil {
+		t.Errorf("expected error for duplicate name")
+	}
+	if err := r.Register("other", &vkoauth.Config{ClientId: "1"}); err == nil {
+		t.Errorf("expected error for duplicate client id")
+	}
+	if err := r.Register("", &vkoauth.Config{ClientId: "10"}); err == nil {
+		t.Errorf("expected error for empty name")
+	}
+	if err := r.Register("no client id", &vkoauth.Config{}); err == nil {
+		t.Errorf("expected error for empty client id")
+	}
+	if _, _, err := r.ByClientId(""); !errors.Is(err, vkoauth.ErrAppNotFound) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := r.Register("nil", nil); err == nil {
+		t.Errorf("expected error for nil config")
+	}
+}
+
+func TestRegistryAmbiguousRedirectUri(t *testing.T) {
+	r := testRegistry(t)
+	if err := r.Register("standalone2", &vkoauth.Config{ClientId: "4", RedirectUri: "https://oauth.vk.com/blank.html"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := r.ByRedirectUri("https://oauth.vk.com/blank.html#code=abc"); !errors.Is(err, vkoauth.ErrAmbiguousApp) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	name, _, err := r.ByRedirectUri("https://example.com/vk/callback")
+	if err != nil || name != "website" {
+		t.Errorf("unexpected result: %q %v", name, err)
+	}
+}
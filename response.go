@@ -0,0 +1,98 @@
+package vkoauth
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"unicode/utf8"
+)
+
+// Максимальный размер ответа сервера в байтах (получение токена, вызов методов API)
+// Ответы большего размера не читаются полностью и возвращают *ResponseError
+var MaxResponseSize int64 = 1 << 20
+
+// Количество байт тела ответа, которое сохраняется в ResponseError.Snippet
+const responseSnippetSize = 512
+
+// Типы содержимого, которые точно не являются JSON (например, страница ошибки прокси)
+var nonJsonContentTypes = map[string]bool{
+	"text/html":             true,
+	"application/xhtml+xml": true,
+	"text/xml":              true,
+	"application/xml":       true,
+}
+
+// Ошибка, которую возвращают запросы библиотеки, если сервер ответил не в формате JSON,
+// прислал некорректный JSON или слишком большой ответ
+type ResponseError struct {
+	Response    *http.Response
+	StatusCode  int    // HTTP код ответа
+	ContentType string // Заголовок Content-Type ответа
+	Snippet     string // Начало тела ответа (не больше 512 байт) для диагностики
+	Message     string // Описание проблемы
+	Err         error  // Исходная ошибка (например, ошибка разбора JSON), может быть nil
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("malformed response (status %d, %q): %s: %q", e.StatusCode, e.ContentType, e.Message, e.Snippet)
+}
+
+func (e *ResponseError) Unwrap() error {
+	return e.Err
+}
+
+func newResponseError(res *http.Response, body []byte, err error, format string, args ...interface{}) *ResponseError {
+	return &ResponseError{
+		Response:    res,
+		StatusCode:  res.StatusCode,
+		ContentType: res.Header.Get("Content-Type"),
+		Snippet:     responseSnippet(body),
+		Message:     fmt.Sprintf(format, args...),
+		Err:         err,
+	}
+}
+
+// Возвращает начало тела ответа, не разрывая последний символ UTF-8
+// Остальные байты сохраняются как есть (тело может быть не в UTF-8, например windows-1251)
+func responseSnippet(body []byte) string {
+	if len(body) <= responseSnippetSize {
+		return string(body)
+	}
+
+	n := responseSnippetSize
+	for i := n - 1; i >= 0 && i > n-utf8.UTFMax; i-- {
+		if utf8.RuneStart(body[i]) {
+			if r, size := utf8.DecodeRune(body[i:]); r != utf8.RuneError && i+size > n {
+				n = i
+			}
+			break
+		}
+	}
+	body = body[:n]
+	return string(body) + "..."
+}
+
+// Читает тело ответа не больше MaxResponseSize байт и закрывает его
+// Возвращает *ResponseError, если ответ слишком большой или точно не является JSON
+func readResponse(res *http.Response) ([]byte, error) {
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(res.Body, MaxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(b)) > MaxResponseSize {
+		return nil, newResponseError(res, b, nil, "response is larger than %d bytes", MaxResponseSize)
+	}
+
+	if contentType := res.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err == nil && nonJsonContentTypes[mediaType] {
+			return nil, newResponseError(res, b, nil, "unexpected content type %s", mediaType)
+		}
+	}
+
+	return b, nil
+}
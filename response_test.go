@@ -0,0 +1,138 @@
+package vkoauth_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/ciricc/vkoauth"
+)
+
+func TestHtmlErrorResponse(t *testing.T) {
+	page := "<html><body><h1>502 Bad Gateway</h1>" + strings.Repeat("ы", 1000) + "</body></html>"
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(page))
+	}))
+	defer serv.Close()
+
+	c := conf(serv.URL)
+	_, err := c.ExchangeCode(context.Background(), "CODE")
+
+	responseErr := &vkoauth.ResponseError{}
+	if !errors.As(err, &responseErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if responseErr.StatusCode != http.StatusBadGateway || responseErr.ContentType != "text/html; charset=utf-8" {
+		t.Errorf("unexpected error: %+v", responseErr)
+	}
+	if !strings.HasPrefix(responseErr.Snippet, "<html><body><h1>502 Bad Gateway</h1>") || len(responseErr.Snippet) > 515 {
+		t.Errorf("unexpected snippet: %q", responseErr.Snippet)
+	}
+	if !strings.HasSuffix(responseErr.Snippet, "ы...") {
+		t.Errorf("snippet must end with a whole character: %q", responseErr.Snippet)
+	}
+}
+
+func TestMalformedJsonResponse(t *testing.T) {
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token":`))
+	}))
+	defer serv.Close()
+
+	c := conf(serv.URL)
+	_, err := c.GetServiceToken(context.Background())
+
+	responseErr := &vkoauth.ResponseError{}
+	if !errors.As(err, &responseErr) || responseErr.Snippet != `{"access_token":` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	syntaxErr := &json.SyntaxError{}
+	if !errors.As(err, &syntaxErr) {
+		t.Errorf("error must wrap json error: %v", err)
+	}
+}
+
+func TestOversizedResponse(t *testing.T) {
+	defer func(size int64) { vkoauth.MaxResponseSize = size }(vkoauth.MaxResponseSize)
+	vkoauth.MaxResponseSize = 64
+
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token":"` + strings.Repeat("a", 100) + `"}`))
+	}))
+	defer serv.Close()
+
+	c := conf(serv.URL)
+	_, err := c.GetServiceToken(context.Background())
+
+	responseErr := &vkoauth.ResponseError{}
+	if !errors.As(err, &responseErr) || responseErr.StatusCode != http.StatusOK {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestApiHtmlResponse(t *testing.T) {
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html>maintenance</html>`))
+	}))
+	defer serv.Close()
+
+	c := apiConf(serv.URL)
+	err := c.CallMethod(context.Background(), vkoauth.StaticTokenSource("USER_TOKEN"), "users.get", nil, nil)
+
+	responseErr := &vkoauth.ResponseError{}
+	if !errors.As(err, &responseErr) || responseErr.Snippet != `<html>maintenance</html>` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNonUtf8ResponseSnippet(t *testing.T) {
+	// "Ошибка" в кодировке windows-1251
+	page := "<html><title>502</title>" + strings.Repeat("\xce\xf8\xe8\xe1\xea\xe0 ", 100) + "</html>"
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=windows-1251")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(page))
+	}))
+	defer serv.Close()
+
+	c := conf(serv.URL)
+	_, err := c.GetServiceToken(context.Background())
+
+	responseErr := &vkoauth.ResponseError{}
+	if !errors.As(err, &responseErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if responseErr.Snippet != page[:512]+"..." {
+		t.Errorf("unexpected snippet: %q", responseErr.Snippet)
+	}
+}
+
+func TestUtf8ResponseSnippetSplitRune(t *testing.T) {
+	// Нечетная длина префикса: символ "ы" (2 байта) попадает на границу фрагмента
+	page := "<html>1" + strings.Repeat("ы", 1000)
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(page))
+	}))
+	defer serv.Close()
+
+	c := conf(serv.URL)
+	_, err := c.GetServiceToken(context.Background())
+
+	responseErr := &vkoauth.ResponseError{}
+	if !errors.As(err, &responseErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if responseErr.Snippet != page[:511]+"..." || !utf8.ValidString(responseErr.Snippet) {
+		t.Errorf("unexpected snippet: %q", responseErr.Snippet)
+	}
+}
@@ -0,0 +1,128 @@
+package scope
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Возвращает права в виде списка названий через запятую (см. String)
+func (s Scope) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Разбирает права из списка названий через запятую или из десятичной битовой маски
+func (s *Scope) UnmarshalText(text []byte) error {
+	v, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*s = v
+	return nil
+}
+
+// Возвращает права в виде JSON массива названий, например ["wall","offline"]
+// Если у части битов нет названий, права записываются числом, чтобы не потерять их
+func (s Scope) MarshalJSON() ([]byte, error) {
+	return marshalJSON(s, UserPermissions)
+}
+
+// Разбирает права из числа, строки ("wall,offline" или "8192") или массива названий
+func (s *Scope) UnmarshalJSON(b []byte) error {
+	v, err := unmarshalJSON(b, UserPermissions, false)
+	if err != nil {
+		return err
+	}
+	if v != nil {
+		*s = *v
+	}
+	return nil
+}
+
+func marshalJSON(s Scope, table []Permission) ([]byte, error) {
+	res := names(s, table)
+	if len(res) > 0 {
+		if _, err := strconv.ParseUint(res[len(res)-1], 10, 64); err == nil {
+			return []byte(strconv.FormatUint(uint64(s), 10)), nil
+		}
+	}
+	return json.Marshal(res)
+}
+
+// Возвращает nil, если в JSON указан null
+func unmarshalJSON(b []byte, table []Permission, group bool) (*Scope, error) {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return nil, fmt.Errorf("empty scope json")
+	}
+
+	switch b[0] {
+	case 'n':
+		if string(b) == "null" {
+			return nil, nil
+		}
+	case '"':
+		str := ""
+		if err := json.Unmarshal(b, &str); err != nil {
+			return nil, err
+		}
+		v, err := parse(str, table, group)
+		return &v, err
+	case '[':
+		list := []string{}
+		if err := json.Unmarshal(b, &list); err != nil {
+			return nil, err
+		}
+		var res Scope
+		for _, name := range list {
+			v, err := parse(name, table, group)
+			if err != nil {
+				return nil, err
+			}
+			res |= v
+		}
+		return &res, nil
+	default:
+		n, err := strconv.ParseUint(string(b), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scope json: %s", string(b))
+		}
+		v := Scope(n)
+		return &v, nil
+	}
+
+	return nil, fmt.Errorf("invalid scope json: %s", string(b))
+}
+
+// Возвращает права сообщества в виде списка названий через запятую (см. String)
+func (s GroupScope) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Разбирает права сообщества из списка названий через запятую или из десятичной битовой маски
+func (s *GroupScope) UnmarshalText(text []byte) error {
+	v, err := ParseGroup(string(text))
+	if err != nil {
+		return err
+	}
+	*s = v
+	return nil
+}
+
+// Возвращает права сообщества в виде JSON массива названий, например ["photos","manage"]
+func (s GroupScope) MarshalJSON() ([]byte, error) {
+	return marshalJSON(Scope(s), GroupPermissions)
+}
+
+// Разбирает права сообщества из числа, строки или массива названий
+func (s *GroupScope) UnmarshalJSON(b []byte) error {
+	v, err := unmarshalJSON(b, GroupPermissions, true)
+	if err != nil {
+		return err
+	}
+	if v != nil {
+		*s = GroupScope(*v)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package scope
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	cases := map[Scope]string{
+		0:                          `[]`,
+		User.Wall | User.Offline:   `["wall","offline"]`,
+		User.Wall | User.Plus256:   `8448`,
+		User.Friends | User.Photos: `["friends","photos"]`,
+	}
+
+	for s, expected := range cases {
+		b, err := json.Marshal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != expected {
+			t.Errorf("unexpected json for %d: %s, expected: %s", uint(s), string(b), expected)
+		}
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	cases := map[string]Scope{
+		`["wall","offline"]`: User.Wall | User.Offline,
+		`"wall,offline"`:     User.Wall | User.Offline,
+		`8448`:               User.Wall | User.Plus256,
+		`"8448"`:             User.Wall | User.Plus256,
+		`[]`:                 0,
+	}
+
+	for body, expected := range cases {
+		var s Scope
+		if err := json.Unmarshal([]byte(body), &s); err != nil {
+			t.Errorf("unexpected error for %s: %v", body, err)
+			continue
+		}
+		if s != expected {
+			t.Errorf("unexpected scope for %s: %d", body, s)
+		}
+	}
+
+	config := struct {
+		Scope Scope `json:"scope"`
+	}{Scope: User.Wall}
+	if err := json.Unmarshal([]byte(`{"scope":null}`), &config); err != nil || config.Scope != User.Wall {
+		t.Errorf("unexpected result for null: %d %v", config.Scope, err)
+	}
+
+	var s Scope
+	err := json.Unmarshal([]byte(`["wall","wal"]`), &s)
+	unknown := &UnknownNameError{}
+	if !errors.As(err, &unknown) || unknown.Name != "wal" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	for _, body := range []string{`true`, `{"wall":1}`, `-1`} {
+		if err := json.Unmarshal([]byte(body), &s); err == nil {
+			t.Errorf("expected error for %s", body)
+		}
+	}
+}
+
+func TestTextMarshaling(t *testing.T) {
+	b, err := (User.Wall | User.Offline).MarshalText()
+	if err != nil || string(b) != "wall,offline" {
+		t.Errorf("unexpected text: %q %v", string(b), err)
+	}
+
+	var s Scope
+	if err := s.UnmarshalText([]byte("wall,offline")); err != nil || s != User.Wall|User.Offline {
+		t.Errorf("unexpected scope: %d %v", s, err)
+	}
+
+	if err := s.UnmarshalText([]byte("8192")); err != nil || s != User.Wall {
+		t.Errorf("unexpected scope: %d %v", s, err)
+	}
+
+	// Ключи map используют TextMarshaler
+	b, err = json.Marshal(map[Scope]int{User.Wall: 1})
+	if err != nil || string(b) != `{"wall":1}` {
+		t.Errorf("unexpected json: %s %v", string(b), err)
+	}
+}
+
+func TestGroupScopeJSON(t *testing.T) {
+	b, err := json.Marshal(Group.Photos | Group.Manage)
+	if err != nil || string(b) != `["photos","manage"]` {
+		t.Errorf("unexpected json: %s %v", string(b), err)
+	}
+
+	var s GroupScope
+	if err := json.Unmarshal([]byte(`["app_widget","docs"]`), &s); err != nil || s != Group.AppWidget|Group.Docs {
+		t.Errorf("unexpected scope: %d %v", s, err)
+	}
+
+	if err := json.Unmarshal([]byte(`["offline"]`), &s); err == nil {
+		t.Errorf("expected error for user permission in group scope")
+	}
+}
@@ -0,0 +1,102 @@
+package scope
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Ошибка разбора списка прав: название не найдено в таблице
+type UnknownNameError struct {
+	Name  string // Неизвестное название
+	Group bool   // Разбирались права сообщества
+}
+
+func (e *UnknownNameError) Error() string {
+	if e.Group {
+		return fmt.Sprintf("unknown group permission name: %q", e.Name)
+	}
+	return fmt.Sprintf("unknown user permission name: %q", e.Name)
+}
+
+// Возвращает названия прав пользователя из таблицы UserPermissions
+// Биты, у которых нет названия, возвращаются одним десятичным числом в конце списка
+func (s Scope) Names() []string {
+	return names(s, UserPermissions)
+}
+
+// Возвращает права пользователя через запятую, например "friends,photos,offline"
+func (s Scope) String() string {
+	return strings.Join(s.Names(), ",")
+}
+
+// Возвращает названия прав сообщества
+// Биты, у которых нет названия, возвращаются одним десятичным числом в конце списка
+func (s GroupScope) Names() []string {
+	return names(Scope(s), GroupPermissions)
+}
+
+// Возвращает права сообщества через запятую, например "photos,manage"
+func (s GroupScope) String() string {
+	return strings.Join(s.Names(), ",")
+}
+
+// Разбирает права пользователя, перечисленные через запятую, например "friends,photos,offline"
+// Вместо названия можно указать десятичное число (битовую маску)
+// Возвращает *UnknownNameError, если название не найдено
+func Parse(s string) (Scope, error) {
+	return parse(s, UserPermissions, false)
+}
+
+// Разбирает права сообщества, перечисленные через запятую, например "photos,manage"
+func ParseGroup(s string) (GroupScope, error) {
+	v, err := parse(s, GroupPermissions, true)
+	return GroupScope(v), err
+}
+
+func names(s Scope, table []Permission) []string {
+	res := []string{}
+	rest := s
+	for _, p := range table {
+		if p.Name != "" && s&p.Scope() != 0 {
+			res = append(res, p.Name)
+			rest &^= p.Scope()
+		}
+	}
+
+	if rest != 0 {
+		res = append(res, strconv.FormatUint(uint64(rest), 10))
+	}
+
+	return res
+}
+
+func parse(s string, table []Permission, group bool) (Scope, error) {
+	var res Scope
+	for _, part := range strings.Split(s, ",") {
+		name := strings.ToLower(strings.TrimSpace(part))
+		if name == "" {
+			continue
+		}
+
+		if n, err := strconv.ParseUint(name, 10, 64); err == nil {
+			res |= Scope(n)
+			continue
+		}
+
+		found := false
+		for _, p := range table {
+			if p.Name != "" && p.Name == name {
+				res |= p.Scope()
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return 0, &UnknownNameError{Name: strings.TrimSpace(part), Group: group}
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,103 @@
+package scope
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	cases := map[Scope]string{
+		0:                                "",
+		User.Messages:                    "messages",
+		User.Friends | User.Photos:       "friends,photos",
+		User.Offline | User.Friends:      "friends,offline",
+		User.Wall | User.Plus256 | 1<<5:  "wall,288",
+		User.Notify | User.Email | 1<<30: "notify,email,1073741824",
+	}
+
+	for s, expected := range cases {
+		if s.String() != expected {
+			t.Errorf("unexpected string for %d: %q, expected: %q", uint(s), s.String(), expected)
+		}
+	}
+
+	if fmt.Sprint(User.Messages) != "messages" {
+		t.Errorf("Scope does not implement fmt.Stringer")
+	}
+}
+
+func TestNames(t *testing.T) {
+	if !reflect.DeepEqual((User.Docs | User.Wall).Names(), []string{"wall", "docs"}) {
+		t.Errorf("unexpected names: %v", (User.Docs | User.Wall).Names())
+	}
+
+	if !reflect.DeepEqual((Group.Manage | Group.Photos).Names(), []string{"photos", "manage"}) {
+		t.Errorf("unexpected group names: %v", (Group.Manage | Group.Photos).Names())
+	}
+
+	if len(Scope(0).Names()) != 0 {
+		t.Errorf("unexpected names for zero scope")
+	}
+}
+
+func TestParse(t *testing.T) {
+	s, err := Parse("friends, photos,offline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != User.Friends|User.Photos|User.Offline {
+		t.Errorf("unexpected scope: %d", s)
+	}
+
+	s, err = Parse("wall,256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != User.Wall|User.Plus256 {
+		t.Errorf("unexpected scope: %d", s)
+	}
+
+	s, err = Parse("")
+	if err != nil || s != 0 {
+		t.Errorf("unexpected result: %d %v", s, err)
+	}
+
+	for _, v := range []Scope{User.All, User.Wall | User.Email, 0} {
+		parsed, err := Parse(v.String())
+		if err != nil || parsed != v {
+			t.Errorf("unexpected round trip for %d: %d %v", uint(v), parsed, err)
+		}
+	}
+}
+
+func TestParseGroup(t *testing.T) {
+	s, err := ParseGroup("app_widget,manage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != Group.AppWidget|Group.Manage {
+		t.Errorf("unexpected scope: %d", s)
+	}
+
+	_, err = ParseGroup("offline")
+	unknown := &UnknownNameError{}
+	if !errors.As(err, &unknown) || unknown.Name != "offline" || !unknown.Group {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestParseUnknownName(t *testing.T) {
+	_, err := Parse("friends,fiends")
+	unknown := &UnknownNameError{}
+	if !errors.As(err, &unknown) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if unknown.Name != "fiends" || unknown.Group {
+		t.Errorf("unexpected error: %+v", unknown)
+	}
+	if err.Error() != `unknown user permission name: "fiends"` {
+		t.Errorf("unexpected error message: %q", err.Error())
+	}
+}
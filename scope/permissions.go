@@ -0,0 +1,200 @@
+package scope
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Описание одного права доступа
+type Permission struct {
+	Bit             uint   // Номер бита в битовой маске
+	Name            string // Название для параметра scope ("", если у права нет строкового названия)
+	Description     string // Описание права
+	Since           string // Версия API, в которой появилось право ("", если доступно во всех версиях)
+	Deprecated      bool   // Право устарело и больше не выдается приложениям
+	DeprecatedSince string // Версия API, начиная с которой право устарело ("", если версия неизвестна)
+	Standalone      bool   // Право выдается только Standalone приложениям
+	Secure          bool   // Другим типам приложений право выдается только при redirect_uri по https
+	Reserved        bool   // Бит зарезервирован и не соответствует никакому праву
+}
+
+// Возвращает битовую маску права
+func (p Permission) Scope() Scope {
+	return 1 << p.Bit
+}
+
+// Таблица прав доступа пользователя в порядке возрастания битов, описывает все биты с 0 по 27
+// Since указан для прав, которые появились позже версии 5.0
+// Смотрите документацию: https://dev.vk.com/reference/access-rights
+var UserPermissions = []Permission{
+	{Bit: 0, Name: "notify", Description: "Пользователь разрешил отправлять ему уведомления", Secure: true},
+	{Bit: 1, Name: "friends", Description: "Доступ к друзьям"},
+	{Bit: 2, Name: "photos", Description: "Доступ к фотографиям"},
+	{Bit: 3, Name: "audio", Description: "Доступ к аудиозаписям", Deprecated: true, DeprecatedSince: "5.62"},
+	{Bit: 4, Name: "video", Description: "Доступ к видеозаписям"},
+	{Bit: 5, Description: "Зарезервировано, не используется", Reserved: true},
+	{Bit: 6, Name: "stories", Description: "Доступ к историям", Since: "5.77"},
+	{Bit: 7, Name: "pages", Description: "Доступ к wiki-страницам"},
+	{Bit: 8, Description: "Добавление ссылки на приложение в меню слева"},
+	{Bit: 9, Description: "Зарезервировано, не используется", Reserved: true},
+	{Bit: 10, Name: "status", Description: "Доступ к статусу пользователя"},
+	{Bit: 11, Name: "notes", Description: "Доступ к заметкам пользователя", Deprecated: true, DeprecatedSince: "5.103"},
+	{Bit: 12, Name: "messages", Description: "Доступ к расширенным методам работы с сообщениями", Standalone: true, Secure: true},
+	{Bit: 13, Name: "wall", Description: "Доступ к обычным и расширенным методам работы со стеной"},
+	{Bit: 14, Description: "Зарезервировано, не используется", Reserved: true},
+	{Bit: 15, Name: "ads", Description: "Доступ к расширенным методам работы с рекламным API"},
+	{Bit: 16, Name: "offline", Description: "Доступ к API в любое время (бессрочный токен)", Secure: true},
+	{Bit: 17, Name: "docs", Description: "Доступ к документам"},
+	{Bit: 18, Name: "groups", Description: "Доступ к группам пользователя"},
+	{Bit: 19, Name: "notifications", Description: "Доступ к оповещениям об ответах пользователю"},
+	{Bit: 20, Name: "stats", Description: "Доступ к статистике групп и приложений пользователя, администратором которых он является"},
+	{Bit: 21, Description: "Зарезервировано, не используется", Reserved: true},
+	{Bit: 22, Name: "email", Description: "Доступ к email пользователя"},
+	{Bit: 23, Description: "Зарезервировано, не используется", Reserved: true},
+	{Bit: 24, Description: "Зарезервировано, не используется", Reserved: true},
+	{Bit: 25, Description: "Зарезервировано, не используется", Reserved: true},
+	{Bit: 26, Description: "Зарезервировано, не используется", Reserved: true},
+	{Bit: 27, Name: "market", Description: "Доступ к товарам", Since: "5.37"},
+}
+
+// Таблица прав доступа сообщества в порядке возрастания битов
+// Биты, которых нет в таблице, не используются
+// Смотрите документацию: https://dev.vk.com/reference/access-rights
+var GroupPermissions = []Permission{
+	{Bit: 0, Name: "stories", Description: "Доступ к историям", Since: "5.77"},
+	{Bit: 2, Name: "photos", Description: "Доступ к фотографиям"},
+	{Bit: 6, Name: "app_widget", Description: "Доступ к виджетам приложений сообществ", Since: "5.70"},
+	{Bit: 12, Name: "messages", Description: "Доступ к сообщениям сообщества"},
+	{Bit: 17, Name: "docs", Description: "Доступ к документам"},
+	{Bit: 18, Name: "manage", Description: "Доступ к администрированию сообщества"},
+}
+
+// Предупреждение о запрошенных правах
+type Warning struct {
+	Bits       Scope       // Биты, к которым относится предупреждение
+	Permission *Permission // Описание права (nil, если биты не описаны в таблице)
+	Message    string
+}
+
+func (w Warning) String() string {
+	return w.Message
+}
+
+// Возвращает описания прав пользователя, входящих в s
+func (s Scope) Permissions() []Permission {
+	return permissionsOf(s, UserPermissions)
+}
+
+// Возвращает описания прав сообщества, входящих в s
+func (s GroupScope) Permissions() []Permission {
+	return permissionsOf(Scope(s), GroupPermissions)
+}
+
+// Возвращает предупреждения о правах пользователя: устаревшие права, права,
+// недоступные в версии API apiVersion, и биты, не описанные в таблице
+// Пустая apiVersion отключает проверку версии
+func (s Scope) Warnings(apiVersion string) []Warning {
+	return warnings(s, UserPermissions, apiVersion)
+}
+
+// Возвращает предупреждения о правах сообщества (см. Scope.Warnings)
+func (s GroupScope) Warnings(apiVersion string) []Warning {
+	return warnings(Scope(s), GroupPermissions, apiVersion)
+}
+
+// Возвращает все действующие (не устаревшие и не зарезервированные) права из таблицы
+func validScope(table []Permission) Scope {
+	var s Scope
+	for _, p := range table {
+		if !p.Deprecated && !p.Reserved {
+			s |= p.Scope()
+		}
+	}
+	return s
+}
+
+// Возвращает все права из таблицы, включая устаревшие (без зарезервированных битов)
+func knownScope(table []Permission) Scope {
+	var s Scope
+	for _, p := range table {
+		if !p.Reserved {
+			s |= p.Scope()
+		}
+	}
+	return s
+}
+
+func permissionsOf(s Scope, table []Permission) []Permission {
+	res := []Permission{}
+	for _, p := range table {
+		if s&p.Scope() != 0 {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+func warnings(s Scope, table []Permission, apiVersion string) []Warning {
+	res := []Warning{}
+	for i := range table {
+		p := &table[i]
+		if s&p.Scope() == 0 || p.Reserved {
+			continue
+		}
+
+		if p.Deprecated && (p.DeprecatedSince == "" || apiVersion == "" || compareVersions(apiVersion, p.DeprecatedSince) >= 0) {
+			res = append(res, Warning{
+				Bits:       p.Scope(),
+				Permission: p,
+				Message:    fmt.Sprintf("permission %s is deprecated", permissionName(p)),
+			})
+		}
+
+		if p.Since != "" && apiVersion != "" && compareVersions(apiVersion, p.Since) < 0 {
+			res = append(res, Warning{
+				Bits:       p.Scope(),
+				Permission: p,
+				Message:    fmt.Sprintf("permission %s is not available before API version %s", permissionName(p), p.Since),
+			})
+		}
+	}
+
+	if unknown := s &^ knownScope(table); unknown != 0 {
+		res = append(res, Warning{
+			Bits:    unknown,
+			Message: fmt.Sprintf("unknown or reserved permission bits: %d", uint(unknown)),
+		})
+	}
+
+	return res
+}
+
+func permissionName(p *Permission) string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return strconv.FormatUint(uint64(p.Scope()), 10)
+}
+
+// Сравнивает версии API вида "5.131", возвращает -1, 0 или 1
+func compareVersions(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
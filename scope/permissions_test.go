@@ -0,0 +1,118 @@
+package scope
+
+import "testing"
+
+func TestAllContainsOnlyValidBits(t *testing.T) {
+	for _, bit := range []uint{5, 9, 14, 21, 23, 24, 25, 26} {
+		if User.All&(1<<bit) != 0 {
+			t.Errorf("User.All contains undefined bit %d", bit)
+		}
+	}
+
+	if !User.All.Has(User.Friends | User.Offline | User.Market | User.Plus256) {
+		t.Errorf("User.All does not contain valid bits: %d", User.All)
+	}
+
+	if User.All.Has(User.Audio) || User.All.Has(User.Notes) {
+		t.Errorf("User.All contains deprecated bits: %d", User.All)
+	}
+
+	if Group.All != Group.Stories|Group.Photos|Group.AppWidget|Group.Messages|Group.Docs|Group.Manage {
+		t.Errorf("unexpected Group.All: %d", Group.All)
+	}
+
+	if len(User.All.Warnings("5.131")) != 0 {
+		t.Errorf("unexpected warnings for User.All: %v", User.All.Warnings("5.131"))
+	}
+}
+
+func TestPermissionsTableOrder(t *testing.T) {
+	for _, table := range [][]Permission{UserPermissions, GroupPermissions} {
+		for i := 1; i < len(table); i++ {
+			if table[i-1].Bit >= table[i].Bit {
+				t.Errorf("permissions table is not sorted at bit %d", table[i].Bit)
+			}
+		}
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	perms := (User.Messages | User.Wall).Permissions()
+	if len(perms) != 2 || perms[0].Name != "messages" || !perms[0].Standalone || perms[1].Name != "wall" {
+		t.Errorf("unexpected permissions: %+v", perms)
+	}
+
+	groupPerms := Group.Manage.Permissions()
+	if len(groupPerms) != 1 || groupPerms[0].Name != "manage" {
+		t.Errorf("unexpected permissions: %+v", groupPerms)
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	w := (User.Audio | User.Wall | 1<<5).Warnings("5.131")
+	if len(w) != 2 {
+		t.Fatalf("unexpected warnings: %v", w)
+	}
+
+	if w[0].Permission == nil || w[0].Permission.Name != "audio" || w[0].Bits != User.Audio {
+		t.Errorf("unexpected deprecated warning: %+v", w[0])
+	}
+
+	if w[1].Permission != nil || w[1].Bits != 1<<5 {
+		t.Errorf("unexpected unknown bits warning: %+v", w[1])
+	}
+
+	if len(User.Notes.Warnings("5.131")) != 1 || len(User.Notes.Warnings("5.101")) != 0 {
+		t.Errorf("unexpected warnings for notes: %v", User.Notes.Warnings("5.101"))
+	}
+
+	if len(GroupScope(1<<30).Warnings("")) != 1 {
+		t.Errorf("expected warning for unknown group bits")
+	}
+}
+
+func TestVersionedWarnings(t *testing.T) {
+	table := []Permission{
+		{Bit: 0, Name: "new", Since: "5.100"},
+		{Bit: 1, Name: "old", Deprecated: true, DeprecatedSince: "5.120"},
+	}
+
+	if len(warnings(3, table, "5.110")) != 0 {
+		t.Errorf("unexpected warnings for 5.110: %v", warnings(3, table, "5.110"))
+	}
+	if len(warnings(3, table, "5.99")) != 1 {
+		t.Errorf("unexpected warnings for 5.99: %v", warnings(3, table, "5.99"))
+	}
+	if len(warnings(3, table, "5.131")) != 1 {
+		t.Errorf("unexpected warnings for 5.131: %v", warnings(3, table, "5.131"))
+	}
+	if len(warnings(1, table, "5.100")) != 0 {
+		t.Errorf("unexpected warnings for 5.100: %v", warnings(1, table, "5.100"))
+	}
+}
+
+func TestUserPermissionsCoverAllBits(t *testing.T) {
+	if len(UserPermissions) != 28 {
+		t.Fatalf("unexpected table size: %d", len(UserPermissions))
+	}
+	for i, p := range UserPermissions {
+		if p.Bit != uint(i) {
+			t.Errorf("missing bit %d", i)
+		}
+		if p.Reserved && (p.Name != "" || User.All&p.Scope() != 0) {
+			t.Errorf("unexpected reserved bit: %+v", p)
+		}
+	}
+}
+
+func TestSinceWarnings(t *testing.T) {
+	if w := User.Market.Warnings("5.30"); len(w) != 1 || w[0].Permission.Name != "market" {
+		t.Errorf("unexpected warnings for 5.30: %v", w)
+	}
+	if w := User.Market.Warnings("5.37"); len(w) != 0 {
+		t.Errorf("unexpected warnings for 5.37: %v", w)
+	}
+	if w := Group.AppWidget.Warnings("5.60"); len(w) != 1 {
+		t.Errorf("unexpected warnings for 5.60: %v", w)
+	}
+}
@@ -1,10 +1,17 @@
 package scope
 
+// Права доступа пользователя
 type Scope uint
+
+// Права доступа сообщества
+// Отдельный тип, потому что одни и те же биты у пользователя и сообщества означают разные права
+type GroupScope uint
+
 type ContextKey struct{}
 
 // Права доступа для пользователя, используйте побитовое ИЛИ, чтобы сложить права вместе
 // User.Docs | User.Offline или используйте User.All
+// User.All содержит все действующие права из таблицы UserPermissions (без устаревших)
 var User = struct {
 	Notify, Friends, Photos,
 	Audio, Video, Stories,
@@ -34,11 +41,12 @@ var User = struct {
 	Stats:         1 << 20,
 	Email:         1 << 22,
 	Market:        1 << 27,
-	All:           Scope(FullFlag(27)),
+	All:           validScope(UserPermissions),
 }
 
 // Права доступа для сообществ, используйте побитовое ИЛИ, чтобы сложить права вместе
 // Group.Stories | Group.Photos или используйте Group.All
+// Group.All содержит все действующие права из таблицы GroupPermissions
 var Group = struct {
 	Stories,
 	Photos,
@@ -46,7 +54,7 @@ var Group = struct {
 	Messages,
 	Docs,
 	Manage,
-	All Scope
+	All GroupScope
 }{
 	Stories:   1 << 0,
 	Photos:    1 << 2,
@@ -54,7 +62,7 @@ var Group = struct {
 	Messages:  1 << 12,
 	Docs:      1 << 17,
 	Manage:    1 << 18,
-	All:       Scope(FullFlag(18)),
+	All:       GroupScope(validScope(GroupPermissions)),
 }
 
 // Возвращает число, (maxVal + 1) первых битов которого = 1
@@ -65,3 +73,44 @@ func FullFlag(maxVal uint) uint {
 	}
 	return v
 }
+
+// Возвращает права из s, которых нет в granted
+// Используйте, чтобы узнать, какие из запрошенных прав пользователь не выдал
+func (s Scope) Missing(granted Scope) Scope {
+	return s &^ granted
+}
+
+// Возвращает true, если s содержит все права other
+func (s Scope) Has(other Scope) bool {
+	return s&other == other
+}
+
+// Разбивает права на отдельные биты в порядке возрастания
+func (s Scope) Split() []Scope {
+	bits := []Scope{}
+	for b := Scope(1); b != 0 && b <= s; b <<= 1 {
+		if s&b != 0 {
+			bits = append(bits, b)
+		}
+	}
+	return bits
+}
+
+// Возвращает права из s, которых нет в granted
+func (s GroupScope) Missing(granted GroupScope) GroupScope {
+	return s &^ granted
+}
+
+// Возвращает true, если s содержит все права other
+func (s GroupScope) Has(other GroupScope) bool {
+	return s&other == other
+}
+
+// Разбивает права на отдельные биты в порядке возрастания
+func (s GroupScope) Split() []GroupScope {
+	bits := []GroupScope{}
+	for _, b := range Scope(s).Split() {
+		bits = append(bits, GroupScope(b))
+	}
+	return bits
+}
@@ -22,3 +22,26 @@ func TestFullFlag(t *testing.T) {
 		}
 	})
 }
+
+func TestMissing(t *testing.T) {
+	requested := User.Friends | User.Photos | User.Offline
+	granted := User.Friends | User.Wall
+
+	missing := requested.Missing(granted)
+	if missing != User.Photos|User.Offline {
+		t.Errorf("unexpected missing scope: %d", missing)
+	}
+
+	if requested.Has(missing) != true || granted.Has(requested) != false {
+		t.Errorf("unexpected Has result")
+	}
+
+	bits := missing.Split()
+	if len(bits) != 2 || bits[0] != User.Photos || bits[1] != User.Offline {
+		t.Errorf("unexpected bits: %v", bits)
+	}
+
+	if len(Scope(0).Split()) != 0 {
+		t.Errorf("unexpected bits for zero scope")
+	}
+}
@@ -0,0 +1,123 @@
+package vkoauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Секретные ключи приложения
+// Previous - предыдущий ключ, который используется на время смены ключа:
+// если сервер ответил invalid_client на запрос с Primary, запрос повторяется один раз с Previous
+type Secrets struct {
+	Primary  string
+	Previous string
+}
+
+// Источник секретного ключа приложения, опрашивается при каждом запросе токена
+type SecretSource interface {
+	Secrets(ctx context.Context) (Secrets, error)
+}
+
+// Функция, реализующая SecretSource
+type SecretFunc func(ctx context.Context) (Secrets, error)
+
+func (f SecretFunc) Secrets(ctx context.Context) (Secrets, error) {
+	return f(ctx)
+}
+
+// Возвращает источник с постоянными ключами
+func StaticSecret(primary, previous string) SecretSource {
+	return SecretFunc(func(ctx context.Context) (Secrets, error) {
+		return Secrets{Primary: primary, Previous: previous}, nil
+	})
+}
+
+// Возвращает источник, который читает ключи из переменных окружения primaryKey и previousKey
+// previousKey может быть пустым
+func EnvSecret(primaryKey, previousKey string) SecretSource {
+	return SecretFunc(func(ctx context.Context) (Secrets, error) {
+		s := Secrets{Primary: os.Getenv(primaryKey)}
+		if s.Primary == "" {
+			return s, fmt.Errorf("environment variable %s is empty", primaryKey)
+		}
+		if previousKey != "" {
+			s.Previous = os.Getenv(previousKey)
+		}
+		return s, nil
+	})
+}
+
+// Возвращает источник, который читает ключи из файла path при каждом запросе
+// Первая непустая строка файла - основной ключ, вторая (необязательно) - предыдущий
+func FileSecret(path string) SecretSource {
+	return SecretFunc(func(ctx context.Context) (Secrets, error) {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return Secrets{}, err
+		}
+
+		lines := []string{}
+		for _, line := range strings.Split(string(b), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+
+		if len(lines) == 0 {
+			return Secrets{}, fmt.Errorf("secret file %s is empty", path)
+		}
+
+		s := Secrets{Primary: lines[0]}
+		if len(lines) > 1 {
+			s.Previous = lines[1]
+		}
+		return s, nil
+	})
+}
+
+// Возвращает секретные ключи из SecretSource или ClientSecret
+func (v *Config) secrets(ctx context.Context) (Secrets, error) {
+	if v.SecretSource == nil {
+		return Secrets{Primary: v.ClientSecret}, nil
+	}
+	return v.SecretSource.Secrets(ctx)
+}
+
+// Делает запрос на получение токена по адресу, который urlOf выбирает из набора адресов
+// Секретный ключ берется из SecretSource; если сервер ответил invalid_client,
+// запрос повторяется один раз с предыдущим ключом
+// При ошибке соединения или ответе 5xx запрос повторяется на следующем наборе из FallbackEndpoints
+func (v *Config) tokenRequest(ctx context.Context, urlOf func(*Endpoint) string, opts ...AuthOption) (*Token, error) {
+	secrets, err := v.secrets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get client secret error: %w", err)
+	}
+
+	err = errors.New("token url is empty")
+	for _, e := range v.endpoints() {
+		baseUrl := urlOf(e)
+		if baseUrl == "" {
+			continue
+		}
+
+		var token *Token
+		token, err = v.doTokenRequest(ctx, v.buildTokenUrl(baseUrl, secrets.Primary, opts...))
+		if secrets.Previous != "" && secrets.Previous != secrets.Primary && isInvalidClient(err) {
+			token, err = v.doTokenRequest(ctx, v.buildTokenUrl(baseUrl, secrets.Previous, opts...))
+		}
+
+		if !shouldFailover(ctx, err) {
+			return token, err
+		}
+	}
+
+	return nil, err
+}
+
+func isInvalidClient(err error) bool {
+	tokenErr := &TokenError{}
+	return errors.As(err, &tokenErr) && tokenErr.ErrorCode == "invalid_client"
+}
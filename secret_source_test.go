@@ -0,0 +1,122 @@
+package vkoauth_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ciricc/vkoauth"
+)
+
+func secretServer(t *testing.T, valid string, secrets *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		secret := r.PostForm.Get("client_secret")
+		*secrets = append(*secrets, secret)
+		if secret != valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client","error_description":"client_secret is incorrect"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"SERVICE_TOKEN"}`))
+	}))
+}
+
+func TestSecretRotationRetry(t *testing.T) {
+	secrets := []string{}
+	serv := secretServer(t, "old", &secrets)
+	defer serv.Close()
+
+	c := conf(serv.URL)
+	c.ClientSecret = ""
+	c.SecretSource = vkoauth.StaticSecret("new", "old")
+
+	token, err := c.GetServiceToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "SERVICE_TOKEN" {
+		t.Errorf("unexpected token: %+v", token)
+	}
+	if !reflect.DeepEqual(secrets, []string{"new", "old"}) {
+		t.Errorf("unexpected secrets: %v", secrets)
+	}
+}
+
+func TestSecretRotationRetryOnce(t *testing.T) {
+	secrets := []string{}
+	serv := secretServer(t, "other", &secrets)
+	defer serv.Close()
+
+	c := conf(serv.URL)
+	c.SecretSource = vkoauth.StaticSecret("new", "old")
+
+	_, err := c.GetServiceToken(context.Background())
+	tokenErr := &vkoauth.TokenError{}
+	if !errors.As(err, &tokenErr) || tokenErr.ErrorCode != "invalid_client" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(secrets, []string{"new", "old"}) {
+		t.Errorf("unexpected secrets: %v", secrets)
+	}
+
+	// Без предыдущего ключа запрос не повторяется
+	secrets = secrets[:0]
+	c.SecretSource = nil
+	c.ClientSecret = "new"
+	c.GetServiceToken(context.Background())
+	if !reflect.DeepEqual(secrets, []string{"new"}) {
+		t.Errorf("unexpected secrets: %v", secrets)
+	}
+}
+
+func TestSecretSourceError(t *testing.T) {
+	c := conf("")
+	c.SecretSource = vkoauth.SecretFunc(func(ctx context.Context) (vkoauth.Secrets, error) {
+		return vkoauth.Secrets{}, errors.New("vault is unavailable")
+	})
+
+	if _, err := c.GetServiceToken(context.Background()); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestFileSecret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	os.WriteFile(path, []byte("new\n\nold\n"), 0600)
+
+	s, err := vkoauth.FileSecret(path).Secrets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Primary != "new" || s.Previous != "old" {
+		t.Errorf("unexpected secrets: %+v", s)
+	}
+
+	os.WriteFile(path, []byte("\n"), 0600)
+	if _, err := vkoauth.FileSecret(path).Secrets(context.Background()); err == nil {
+		t.Errorf("expected error for empty file")
+	}
+}
+
+func TestEnvSecret(t *testing.T) {
+	t.Setenv("TEST_VK_SECRET", "new")
+	t.Setenv("TEST_VK_SECRET_OLD", "old")
+
+	s, err := vkoauth.EnvSecret("TEST_VK_SECRET", "TEST_VK_SECRET_OLD").Secrets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Primary != "new" || s.Previous != "old" {
+		t.Errorf("unexpected secrets: %+v", s)
+	}
+
+	if _, err := vkoauth.EnvSecret("TEST_VK_SECRET_MISSING", "").Secrets(context.Background()); err == nil {
+		t.Errorf("expected error for empty variable")
+	}
+}
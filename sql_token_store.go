@@ -0,0 +1,310 @@
+package vkoauth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ошибка, которую возвращает SQLTokenStore.CompareAndPut, если токен был изменен другим процессом
+var ErrTokenConflict = errors.New("token was modified concurrently")
+
+// Стиль плейсхолдеров в SQL запросах, зависит от драйвера
+type SQLPlaceholder int
+
+const SQLPlaceholderQuestion SQLPlaceholder = 0 // ?, ?, ? (SQLite, MySQL)
+const SQLPlaceholderDollar SQLPlaceholder = 1   // $1, $2, $3 (Postgres)
+
+// Таблица по умолчанию для SQLTokenStore
+const DefaultSQLTokenTable = "vkoauth_tokens"
+
+var sqlIdentifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Настройки SQLTokenStore
+type SQLTokenStoreOptions struct {
+	Table       string         // Имя таблицы (DefaultSQLTokenTable, если не указано)
+	Placeholder SQLPlaceholder // Стиль плейсхолдеров драйвера
+}
+
+// Хранилище токенов поверх database/sql, работает с любым драйвером
+// Токен хранится в виде JSON (см. Token.MarshalJSON), рядом хранятся индексируемые
+// идентификаторы пользователя и сообщества, тип токена и дата истечения (unix время в секундах)
+// Каждая запись имеет версию, которая увеличивается при каждой записи, это позволяет
+// использовать CompareAndPut для оптимистичной блокировки
+// Схема создается запросами, которые поддерживают SQLite, PostgreSQL и MySQL
+type SQLTokenStore struct {
+	db          *sql.DB
+	table       string
+	placeholder SQLPlaceholder
+}
+
+// Создает хранилище и, если нужно, таблицу и индексы
+func NewSQLTokenStore(ctx context.Context, db *sql.DB, opts SQLTokenStoreOptions) (*SQLTokenStore, error) {
+	s := &SQLTokenStore{
+		db:          db,
+		table:       opts.Table,
+		placeholder: opts.Placeholder,
+	}
+
+	if s.table == "" {
+		s.table = DefaultSQLTokenTable
+	}
+
+	if !sqlIdentifierRegexp.MatchString(s.table) {
+		return nil, fmt.Errorf("invalid table name: %q", s.table)
+	}
+
+	if err := s.createSchema(ctx); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Создает таблицу и индексы, если таблицы еще нет
+// MySQL не поддерживает CREATE INDEX IF NOT EXISTS, поэтому индексы создаются только вместе с таблицей,
+// а ошибка "индекс уже существует" (таблицу одновременно создал другой процесс) игнорируется
+func (s *SQLTokenStore) createSchema(ctx context.Context) error {
+	if s.tableExists(ctx) {
+		return nil
+	}
+
+	_, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+s.table+` (
+	client_id VARCHAR(64) NOT NULL,
+	user_id BIGINT NOT NULL,
+	group_id BIGINT NOT NULL,
+	kind VARCHAR(16) NOT NULL,
+	expires_at BIGINT,
+	version BIGINT NOT NULL,
+	data TEXT NOT NULL,
+	PRIMARY KEY (client_id, user_id, group_id)
+)`)
+	if err != nil {
+		return fmt.Errorf("create token store schema error: %w", err)
+	}
+
+	for _, column := range []string{"user_id", "group_id", "kind", "expires_at"} {
+		_, err := s.db.ExecContext(ctx, `CREATE INDEX `+s.table+`_`+column+` ON `+s.table+` (`+column+`)`)
+		if err != nil && !isAlreadyExists(err) {
+			return fmt.Errorf("create token store schema error: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Проверяет существование таблицы запросом, который понимают все СУБД
+func (s *SQLTokenStore) tableExists(ctx context.Context) bool {
+	rows, err := s.db.QueryContext(ctx, `SELECT 1 FROM `+s.table+` WHERE 1 = 0`)
+	if err != nil {
+		return false
+	}
+	rows.Close()
+	return true
+}
+
+// Проверяет, что ошибка означает уже существующий объект схемы:
+// "already exists" (SQLite, PostgreSQL) или "Duplicate key name" (MySQL)
+func isAlreadyExists(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "already exists") || strings.Contains(msg, "duplicate key name")
+}
+
+func (s *SQLTokenStore) Get(ctx context.Context, key TokenKey) (*Token, error) {
+	token, _, err := s.GetVersion(ctx, key)
+	return token, err
+}
+
+// Возвращает токен и версию записи, которую нужно передать в CompareAndPut
+func (s *SQLTokenStore) GetVersion(ctx context.Context, key TokenKey) (*Token, int64, error) {
+	row := s.db.QueryRowContext(ctx, s.query(
+		`SELECT data, version FROM `+s.table+` WHERE client_id = ? AND user_id = ? AND group_id = ?`,
+	), key.ClientId, key.UserId, key.GroupId)
+
+	var data string
+	var version int64
+	err := row.Scan(&data, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, 0, ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	token := &Token{}
+	if err := json.Unmarshal([]byte(data), token); err != nil {
+		return nil, 0, fmt.Errorf("parse stored token %s error: %w", key, err)
+	}
+
+	return token, version, nil
+}
+
+func (s *SQLTokenStore) Put(ctx context.Context, key TokenKey, token *Token) error {
+	data, expires, err := s.encode(token)
+	if err != nil {
+		return err
+	}
+
+	updated, err := s.update(ctx, key, data, expires, -1)
+	if err != nil || updated {
+		return err
+	}
+
+	err = s.insert(ctx, key, data, expires)
+	if err == nil {
+		return nil
+	}
+
+	// Запись могла быть создана другим процессом между UPDATE и INSERT
+	updated, updateErr := s.update(ctx, key, data, expires, -1)
+	if updateErr == nil && updated {
+		return nil
+	}
+	return err
+}
+
+// Сохраняет токен, только если версия записи в базе совпадает с version
+// version = 0 означает, что записи еще не должно существовать
+// Возвращает ErrTokenConflict, если запись была изменена (или создана) другим процессом
+func (s *SQLTokenStore) CompareAndPut(ctx context.Context, key TokenKey, token *Token, version int64) error {
+	data, expires, err := s.encode(token)
+	if err != nil {
+		return err
+	}
+
+	if version == 0 {
+		err := s.insert(ctx, key, data, expires)
+		if err == nil {
+			return nil
+		}
+		if _, _, getErr := s.GetVersion(ctx, key); getErr == nil {
+			return ErrTokenConflict
+		}
+		return err
+	}
+
+	updated, err := s.update(ctx, key, data, expires, version)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrTokenConflict
+	}
+	return nil
+}
+
+func (s *SQLTokenStore) Delete(ctx context.Context, key TokenKey) error {
+	_, err := s.db.ExecContext(ctx, s.query(
+		`DELETE FROM `+s.table+` WHERE client_id = ? AND user_id = ? AND group_id = ?`,
+	), key.ClientId, key.UserId, key.GroupId)
+	return err
+}
+
+func (s *SQLTokenStore) List(ctx context.Context) ([]TokenKey, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT client_id, user_id, group_id FROM `+s.table+` ORDER BY client_id, user_id, group_id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []TokenKey{}
+	for rows.Next() {
+		k := TokenKey{}
+		if err := rows.Scan(&k.ClientId, &k.UserId, &k.GroupId); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+
+	return keys, rows.Err()
+}
+
+func (s *SQLTokenStore) Prune(ctx context.Context, now time.Time) (int, error) {
+	res, err := s.db.ExecContext(ctx, s.query(
+		`DELETE FROM `+s.table+` WHERE expires_at IS NOT NULL AND expires_at <= ?`,
+	), now.Unix())
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// Обновляет запись и увеличивает ее версию
+// Если version >= 0, обновление произойдет только при совпадении версии
+func (s *SQLTokenStore) update(ctx context.Context, key TokenKey, data string, expires sql.NullInt64, version int64) (bool, error) {
+	q := `UPDATE ` + s.table + ` SET data = ?, expires_at = ?, version = version + 1 WHERE client_id = ? AND user_id = ? AND group_id = ?`
+	args := []interface{}{data, expires, key.ClientId, key.UserId, key.GroupId}
+
+	if version >= 0 {
+		q += ` AND version = ?`
+		args = append(args, version)
+	}
+
+	res, err := s.db.ExecContext(ctx, s.query(q), args...)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (s *SQLTokenStore) insert(ctx context.Context, key TokenKey, data string, expires sql.NullInt64) error {
+	_, err := s.db.ExecContext(ctx, s.query(
+		`INSERT INTO `+s.table+` (client_id, user_id, group_id, kind, expires_at, version, data) VALUES (?, ?, ?, ?, ?, 1, ?)`,
+	), key.ClientId, key.UserId, key.GroupId, string(key.Kind()), expires, data)
+	return err
+}
+
+// Сериализует токен и вычисляет дату истечения для индекса
+// Дата округляется вверх до секунды, чтобы Prune не удалял токен раньше срока
+func (s *SQLTokenStore) encode(token *Token) (string, sql.NullInt64, error) {
+	if token == nil {
+		return "", sql.NullInt64{}, fmt.Errorf("token is nil")
+	}
+
+	b, err := json.Marshal(token)
+	if err != nil {
+		return "", sql.NullInt64{}, err
+	}
+
+	expires := sql.NullInt64{}
+	if token.Expires != nil {
+		expires.Valid = true
+		expires.Int64 = token.Expires.Unix()
+		if token.Expires.Nanosecond() > 0 {
+			expires.Int64++
+		}
+	}
+
+	return string(b), expires, nil
+}
+
+// Заменяет плейсхолдеры ? на стиль драйвера
+func (s *SQLTokenStore) query(q string) string {
+	if s.placeholder != SQLPlaceholderDollar {
+		return q
+	}
+
+	b := strings.Builder{}
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
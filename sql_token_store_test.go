@@ -0,0 +1,283 @@
+package vkoauth_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ciricc/vkoauth"
+)
+
+// Минимальный драйвер database/sql, который понимает только запросы SQLTokenStore
+// Как и MySQL, не поддерживает CREATE INDEX IF NOT EXISTS
+type fakeSQL struct {
+	mu      sync.Mutex
+	rows    map[vkoauth.TokenKey]*fakeSQLRow
+	queries []string
+	tables  map[string]bool
+	indexes map[string]bool
+}
+
+type fakeSQLRow struct {
+	kind    string
+	expires interface{}
+	version int64
+	data    string
+}
+
+func (d *fakeSQL) Connect(ctx context.Context) (driver.Conn, error) { return &fakeSQLConn{d}, nil }
+func (d *fakeSQL) Driver() driver.Driver                            { return nil }
+
+type fakeSQLConn struct{ d *fakeSQL }
+
+func (c *fakeSQLConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeSQLStmt{c.d, query}, nil
+}
+func (c *fakeSQLConn) Close() error              { return nil }
+func (c *fakeSQLConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+type fakeSQLStmt struct {
+	d     *fakeSQL
+	query string
+}
+
+func (s *fakeSQLStmt) Close() error  { return nil }
+func (s *fakeSQLStmt) NumInput() int { return -1 }
+
+func fakeKey(args []driver.Value) vkoauth.TokenKey {
+	return vkoauth.TokenKey{ClientId: args[0].(string), UserId: args[1].(int64), GroupId: args[2].(int64)}
+}
+
+func (s *fakeSQLStmt) Exec(args []driver.Value) (driver.Result, error) {
+	d := s.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queries = append(d.queries, s.query)
+
+	switch {
+	case strings.HasPrefix(s.query, "CREATE TABLE IF NOT EXISTS "):
+		d.tables[strings.Fields(s.query)[5]] = true
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(s.query, "CREATE INDEX IF NOT EXISTS"):
+		return nil, errors.New("You have an error in your SQL syntax")
+	case strings.HasPrefix(s.query, "CREATE INDEX "):
+		name := strings.Fields(s.query)[2]
+		if d.indexes[name] {
+			return nil, fmt.Errorf("Duplicate key name '%s'", name)
+		}
+		d.indexes[name] = true
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(s.query, "UPDATE"):
+		row, ok := d.rows[fakeKey(args[2:])]
+		if !ok || (len(args) > 5 && row.version != args[5].(int64)) {
+			return driver.RowsAffected(0), nil
+		}
+		row.data, row.expires = args[0].(string), args[1]
+		row.version++
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "INSERT"):
+		key := fakeKey(args)
+		if _, ok := d.rows[key]; ok {
+			return nil, errors.New("unique constraint violation")
+		}
+		d.rows[key] = &fakeSQLRow{kind: args[3].(string), expires: args[4], version: 1, data: args[5].(string)}
+		return driver.RowsAffected(1), nil
+	case strings.Contains(s.query, "expires_at <="):
+		n := 0
+		for k, row := range d.rows {
+			if e, ok := row.expires.(int64); ok && e <= args[0].(int64) {
+				delete(d.rows, k)
+				n++
+			}
+		}
+		return driver.RowsAffected(n), nil
+	case strings.HasPrefix(s.query, "DELETE"):
+		delete(d.rows, fakeKey(args))
+		return driver.RowsAffected(1), nil
+	}
+	return nil, fmt.Errorf("unexpected query: %s", s.query)
+}
+
+func (s *fakeSQLStmt) Query(args []driver.Value) (driver.Rows, error) {
+	d := s.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queries = append(d.queries, s.query)
+
+	switch {
+	case strings.HasPrefix(s.query, "SELECT 1 FROM "):
+		if !d.tables[strings.Fields(s.query)[3]] {
+			return nil, fmt.Errorf("Table '%s' doesn't exist", strings.Fields(s.query)[3])
+		}
+		return &fakeSQLRows{columns: []string{"1"}}, nil
+	case strings.HasPrefix(s.query, "SELECT data"):
+		row, ok := d.rows[fakeKey(args)]
+		if !ok {
+			return &fakeSQLRows{columns: []string{"data", "version"}}, nil
+		}
+		return &fakeSQLRows{columns: []string{"data", "version"}, values: [][]driver.Value{{row.data, row.version}}}, nil
+	case strings.HasPrefix(s.query, "SELECT client_id"):
+		keys := []vkoauth.TokenKey{}
+		for k := range d.rows {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].UserId != keys[j].UserId {
+				return keys[i].UserId < keys[j].UserId
+			}
+			return keys[i].GroupId < keys[j].GroupId
+		})
+		values := [][]driver.Value{}
+		for _, k := range keys {
+			values = append(values, []driver.Value{k.ClientId, k.UserId, k.GroupId})
+		}
+		return &fakeSQLRows{columns: []string{"client_id", "user_id", "group_id"}, values: values}, nil
+	}
+	return nil, fmt.Errorf("unexpected query: %s", s.query)
+}
+
+type fakeSQLRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeSQLRows) Columns() []string { return r.columns }
+func (r *fakeSQLRows) Close() error      { return nil }
+func (r *fakeSQLRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func newFakeSQL() *fakeSQL {
+	return &fakeSQL{
+		rows:    make(map[vkoauth.TokenKey]*fakeSQLRow),
+		tables:  make(map[string]bool),
+		indexes: make(map[string]bool),
+	}
+}
+
+func newFakeSQLStore(t *testing.T, opts vkoauth.SQLTokenStoreOptions) (*vkoauth.SQLTokenStore, *fakeSQL) {
+	d := newFakeSQL()
+	store, err := vkoauth.NewSQLTokenStore(context.Background(), sql.OpenDB(d), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, d
+}
+
+func TestSQLTokenStore(t *testing.T) {
+	store, d := newFakeSQLStore(t, vkoauth.SQLTokenStoreOptions{})
+	testTokenStore(t, store)
+
+	if !strings.Contains(d.queries[1], "CREATE TABLE IF NOT EXISTS vkoauth_tokens") {
+		t.Errorf("unexpected schema query: %s", d.queries[1])
+	}
+	if len(d.indexes) != 4 {
+		t.Errorf("unexpected indexes: %v", d.indexes)
+	}
+}
+
+func TestSQLTokenStoreExistingSchema(t *testing.T) {
+	ctx := context.Background()
+	d := newFakeSQL()
+	db := sql.OpenDB(d)
+
+	if _, err := vkoauth.NewSQLTokenStore(ctx, db, vkoauth.SQLTokenStoreOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Таблица уже есть: схема не создается повторно
+	d.queries = nil
+	if _, err := vkoauth.NewSQLTokenStore(ctx, db, vkoauth.SQLTokenStoreOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.queries) != 1 || !strings.HasPrefix(d.queries[0], "SELECT 1 FROM") {
+		t.Errorf("unexpected queries: %v", d.queries)
+	}
+
+	// Таблицу и индексы одновременно создал другой процесс
+	d.tables = map[string]bool{}
+	if _, err := vkoauth.NewSQLTokenStore(ctx, db, vkoauth.SQLTokenStoreOptions{}); err != nil {
+		t.Errorf("duplicate index error must be ignored: %v", err)
+	}
+}
+
+func TestSQLTokenStoreIndexedColumns(t *testing.T) {
+	ctx := context.Background()
+	store, d := newFakeSQLStore(t, vkoauth.SQLTokenStoreOptions{})
+	expires := time.Unix(1666699200, 500)
+
+	store.Put(ctx, vkoauth.TokenKey{ClientId: "1", GroupId: 10}, &vkoauth.Token{AccessToken: "token", Expires: &expires})
+
+	row := d.rows[vkoauth.TokenKey{ClientId: "1", GroupId: 10}]
+	if row.kind != "group" {
+		t.Errorf("unexpected kind: %q", row.kind)
+	}
+	if row.expires != int64(1666699201) {
+		t.Errorf("unexpected expires: %v", row.expires)
+	}
+}
+
+func TestSQLTokenStoreCompareAndPut(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newFakeSQLStore(t, vkoauth.SQLTokenStoreOptions{})
+	key := vkoauth.TokenKey{ClientId: "1", UserId: 1}
+
+	if err := store.CompareAndPut(ctx, key, &vkoauth.Token{AccessToken: "first"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CompareAndPut(ctx, key, &vkoauth.Token{AccessToken: "again"}, 0); !errors.Is(err, vkoauth.ErrTokenConflict) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	_, version, err := store.GetVersion(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Два процесса прочитали одну версию, второй должен получить конфликт
+	if err := store.CompareAndPut(ctx, key, &vkoauth.Token{AccessToken: "refreshed-a"}, version); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CompareAndPut(ctx, key, &vkoauth.Token{AccessToken: "refreshed-b"}, version); !errors.Is(err, vkoauth.ErrTokenConflict) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	token, newVersion, _ := store.GetVersion(ctx, key)
+	if token.AccessToken != "refreshed-a" || newVersion != version+1 {
+		t.Errorf("unexpected token: %+v, version: %d", token, newVersion)
+	}
+}
+
+func TestSQLTokenStoreDollarPlaceholders(t *testing.T) {
+	store, d := newFakeSQLStore(t, vkoauth.SQLTokenStoreOptions{
+		Table:       "tokens",
+		Placeholder: vkoauth.SQLPlaceholderDollar,
+	})
+
+	store.Get(context.Background(), vkoauth.TokenKey{ClientId: "1", UserId: 1})
+	q := d.queries[len(d.queries)-1]
+	if q != "SELECT data, version FROM tokens WHERE client_id = $1 AND user_id = $2 AND group_id = $3" {
+		t.Errorf("unexpected query: %s", q)
+	}
+}
+
+func TestSQLTokenStoreInvalidTable(t *testing.T) {
+	d := newFakeSQL()
+	_, err := vkoauth.NewSQLTokenStore(context.Background(), sql.OpenDB(d), vkoauth.SQLTokenStoreOptions{Table: "tokens; DROP TABLE users"})
+	if err == nil {
+		t.Errorf("expected error")
+	}
+}
@@ -18,6 +18,9 @@ type TokenErrorJson struct {
 	ErrorType        string `json:"error_type,omitempty"`
 }
 
+// Код ошибки TokenError, если сервер ответил успешно, но не прислал ни одного ключа доступа
+const ErrorCodeEmptyToken = "empty_access_token"
+
 type TokenError struct {
 	Response         *http.Response
 	Body             []byte
@@ -31,8 +34,59 @@ type TokenError struct {
 	ValidationResend string
 	CaptchaSid       string
 	CaptchaImg       string
+	Lang             string // Язык запроса (параметр lang), на котором ВКонтакте вернул описание ошибки ("", если не указан)
 }
 
 func (e *TokenError) Error() string {
 	return fmt.Sprintf("Get token error: %s %s", e.ErrorCode, e.description)
 }
+
+func newTokenError(res *http.Response, body []byte, errJson TokenErrorJson, lang string) *TokenError {
+	return &TokenError{
+		Response:         res,
+		Body:             body,
+		RedirectURI:      errJson.RedirectURI,
+		ErrorCode:        errJson.Error,
+		ValidationType:   errJson.ValidationType,
+		ValidationSid:    errJson.ValidationSid,
+		PhoneMask:        errJson.PhoneMask,
+		ValidationResend: errJson.ValidationResend,
+		CaptchaSid:       errJson.CaptchaSid,
+		CaptchaImg:       errJson.CaptchaImg,
+		description:      errJson.ErrorDescription,
+		ErrorType:        errJson.ErrorType,
+		Lang:             lang,
+	}
+}
+
+// Возвращает описание ошибки от ВКонтакте (error_description)
+// Язык описания зависит от параметра lang запроса (AuthParams.Lang, опция Lang)
+func (e *TokenError) Description() string {
+	return e.description
+}
+
+// Возвращает текст ошибки для пользователя на языке lang
+// Если запрос был отправлен на языке lang и ВКонтакте вернул описание, возвращается описание
+// Иначе текст берется из ErrorMessages по ErrorType, затем по ErrorCode,
+// если сообщения нет - возвращается описание от ВКонтакте или общее сообщение об ошибке
+func (e *TokenError) Message(lang string) string {
+	if e.description != "" && e.Lang != "" && e.Lang == lang {
+		return e.description
+	}
+
+	for _, code := range []string{e.ErrorType, e.ErrorCode} {
+		if code == "" {
+			continue
+		}
+		if msg, ok := ErrorMessage(lang, code); ok {
+			return msg
+		}
+	}
+
+	if e.description != "" {
+		return e.description
+	}
+
+	msg, _ := ErrorMessage(lang, "")
+	return msg
+}
@@ -0,0 +1,103 @@
+package vkoauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Текущая версия JSON представления Token
+const TokenJsonVersion = 1
+
+// JSON представление Token (версия 1):
+//
+//	{
+//	  "version": 1,
+//	  "access_token": "...",
+//	  "user_id": 66748,
+//	  "expires_at": "2022-10-25T12:00:00Z", // null, если токен бессрочный
+//	  "state": "...",
+//	  "groups": [{"group_id": 123456, "access_token": "..."}],
+//	  "raw": {...}
+//	}
+//
+// Дата истечения хранится как абсолютное время в UTC (RFC 3339)
+type tokenJson struct {
+	Version     int                    `json:"version"`
+	AccessToken string                 `json:"access_token"`
+	UserId      int64                  `json:"user_id"`
+	ExpiresAt   *time.Time             `json:"expires_at"`
+	State       string                 `json:"state"`
+	Groups      []*groupTokenJson      `json:"groups"`
+	Raw         map[string]interface{} `json:"raw"`
+}
+
+type groupTokenJson struct {
+	GroupId     int64  `json:"group_id"`
+	AccessToken string `json:"access_token"`
+}
+
+// Сериализует токен в версионированный JSON формат
+func (t Token) MarshalJSON() ([]byte, error) {
+	v := tokenJson{
+		Version:     TokenJsonVersion,
+		AccessToken: t.AccessToken,
+		UserId:      t.UserId,
+		State:       t.State,
+		Raw:         t.Raw,
+	}
+
+	if t.Expires != nil {
+		e := t.Expires.UTC()
+		v.ExpiresAt = &e
+	}
+
+	if t.Groups != nil {
+		v.Groups = make([]*groupTokenJson, len(t.Groups))
+		for i, g := range t.Groups {
+			if g != nil {
+				v.Groups[i] = &groupTokenJson{
+					GroupId:     g.GroupId,
+					AccessToken: g.AccessToken,
+				}
+			}
+		}
+	}
+
+	return json.Marshal(v)
+}
+
+// Восстанавливает токен из JSON формата, созданного MarshalJSON
+// Возвращает ошибку, если версия формата не поддерживается
+func (t *Token) UnmarshalJSON(b []byte) error {
+	v := tokenJson{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	if v.Version != TokenJsonVersion {
+		return fmt.Errorf("unsupported token json version: %d", v.Version)
+	}
+
+	*t = Token{
+		AccessToken: v.AccessToken,
+		UserId:      v.UserId,
+		Expires:     v.ExpiresAt,
+		State:       v.State,
+		Raw:         v.Raw,
+	}
+
+	if v.Groups != nil {
+		t.Groups = make([]*GroupToken, len(v.Groups))
+		for i, g := range v.Groups {
+			if g != nil {
+				t.Groups[i] = &GroupToken{
+					GroupId:     g.GroupId,
+					AccessToken: g.AccessToken,
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package vkoauth_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ciricc/vkoauth"
+)
+
+func TestTokenJsonRoundTrip(t *testing.T) {
+	expires := time.Date(2022, 10, 25, 12, 0, 0, 0, time.UTC)
+	token := vkoauth.Token{
+		AccessToken: "533bacf01e11f55b536a565b57531ac114461ae8736d6506a3",
+		UserId:      66748,
+		Expires:     &expires,
+		State:       "123456",
+		Groups: []*vkoauth.GroupToken{
+			{GroupId: 123456, AccessToken: "a740d2bfe91caaa6eab794e1168da38cdaedc93c92f233638f"},
+		},
+		Raw: map[string]interface{}{
+			"access_token": "533bacf01e11f55b536a565b57531ac114461ae8736d6506a3",
+			"expires_in":   float64(43200),
+			"user_id":      float64(66748),
+		},
+	}
+
+	b, err := json.Marshal(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := vkoauth.Token{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.Expires == nil || !decoded.Expires.Equal(expires) {
+		t.Errorf("unexpected expires: %v", decoded.Expires)
+	}
+
+	decoded.Expires = token.Expires
+	if !reflect.DeepEqual(token, decoded) {
+		t.Errorf("unexpected token: %+v", decoded)
+	}
+}
+
+func TestTokenJsonShape(t *testing.T) {
+	expires := time.Date(2022, 10, 25, 15, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	b, err := json.Marshal(&vkoauth.Token{
+		AccessToken: "token",
+		UserId:      1,
+		Expires:     &expires,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"version":1,"access_token":"token","user_id":1,"expires_at":"2022-10-25T12:00:00Z","state":"","groups":null,"raw":null}`
+	if string(b) != expected {
+		t.Errorf("unexpected json: %s", string(b))
+	}
+}
+
+func TestTokenJsonNilExpires(t *testing.T) {
+	b, err := json.Marshal(vkoauth.Token{AccessToken: "token", Groups: []*vkoauth.GroupToken{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := vkoauth.Token{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.Expires != nil {
+		t.Errorf("unexpected expires: %v", decoded.Expires)
+	}
+
+	if decoded.Groups == nil || len(decoded.Groups) != 0 {
+		t.Errorf("unexpected groups: %v", decoded.Groups)
+	}
+}
+
+func TestTokenJsonUnsupportedVersion(t *testing.T) {
+	for _, body := range []string{`{"access_token":"token"}`, `{"version":2,"access_token":"token"}`} {
+		token := vkoauth.Token{}
+		if err := json.Unmarshal([]byte(body), &token); err == nil {
+			t.Errorf("expected error for %s", body)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package vkoauth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ciricc/vkoauth/scope"
+)
+
+// Ошибка, которую возвращает TokenPool, если нет подходящего доступного токена
+var ErrNoTokenAvailable = errors.New("no token available")
+
+// Время, на которое токен выводится из ротации по умолчанию
+const DefaultPoolInvalidCooldown = 10 * time.Minute
+const DefaultPoolFloodCooldown = time.Minute
+
+// Настройки TokenPool
+type TokenPoolOptions struct {
+	InvalidCooldown time.Duration    // На сколько токен выводится из ротации после ReportInvalid
+	FloodCooldown   time.Duration    // На сколько токен выводится из ротации после ReportFlood
+	Now             func() time.Time // Источник текущего времени (time.Now, если не указан)
+}
+
+// Пул токенов нескольких аккаунтов и сообществ
+// Выдает наименее используемый действующий токен, временно выводит из ротации токены,
+// о которых сообщили как о недействительных или упершихся в лимит запросов
+// Безопасен для конкурентного использования
+type TokenPool struct {
+	mu      sync.Mutex
+	opts    TokenPoolOptions
+	entries []*poolEntry
+}
+
+type poolEntry struct {
+	key           TokenKey
+	token         *Token
+	scope         scope.Scope
+	uses          uint64
+	disabledUntil time.Time
+}
+
+// Создает пустой пул токенов
+func NewTokenPool(opts TokenPoolOptions) *TokenPool {
+	if opts.InvalidCooldown <= 0 {
+		opts.InvalidCooldown = DefaultPoolInvalidCooldown
+	}
+	if opts.FloodCooldown <= 0 {
+		opts.FloodCooldown = DefaultPoolFloodCooldown
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &TokenPool{opts: opts}
+}
+
+// Добавляет токен в пул или заменяет токен с тем же ключом
+// s - права доступа, выданные токену (для токенов сообществ не учитываются)
+// Пустой токен (nil) не добавляется
+func (p *TokenPool) Add(key TokenKey, token *Token, s scope.Scope) {
+	if token == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, e := range p.entries {
+		if e.key == key {
+			e.token = cloneToken(token)
+			e.scope = s
+			e.disabledUntil = time.Time{}
+			return
+		}
+	}
+
+	p.entries = append(p.entries, &poolEntry{
+		key:   key,
+		token: cloneToken(token),
+		scope: s,
+	})
+}
+
+// Добавляет в пул все токены из хранилища с правами доступа s
+func (p *TokenPool) Load(ctx context.Context, store TokenStore, s scope.Scope) error {
+	keys, err := store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		token, err := store.Get(ctx, key)
+		if errors.Is(err, ErrTokenNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		p.Add(key, token, s)
+	}
+
+	return nil
+}
+
+// Удаляет токен из пула
+func (p *TokenPool) Remove(key TokenKey) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, e := range p.entries {
+		if e.key == key {
+			p.entries = append(p.entries[:i], p.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// Возвращает наименее используемый действующий токен пользователя или приложения,
+// которому выданы все права s
+func (p *TokenPool) Acquire(s scope.Scope) (TokenKey, *Token, error) {
+	return p.acquire(func(e *poolEntry) bool {
+		return e.key.Kind() != TokenKindGroup && e.scope&s == s
+	})
+}
+
+// Возвращает наименее используемый действующий токен сообщества groupId
+func (p *TokenPool) AcquireGroup(groupId int64) (TokenKey, *Token, error) {
+	return p.acquire(func(e *poolEntry) bool {
+		return e.key.Kind() == TokenKindGroup && e.key.GroupId == groupId
+	})
+}
+
+func (p *TokenPool) acquire(match func(e *poolEntry) bool) (TokenKey, *Token, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.opts.Now()
+
+	var best *poolEntry
+	for _, e := range p.entries {
+		if !match(e) || tokenExpired(e.token, now) || now.Before(e.disabledUntil) {
+			continue
+		}
+		if best == nil || e.uses < best.uses {
+			best = e
+		}
+	}
+
+	if best == nil {
+		return TokenKey{}, nil, ErrNoTokenAvailable
+	}
+
+	best.uses++
+	return best.key, cloneToken(best.token), nil
+}
+
+// Выводит токен из ротации на InvalidCooldown, например, после ошибки авторизации API
+func (p *TokenPool) ReportInvalid(key TokenKey) {
+	p.disable(key, p.opts.InvalidCooldown)
+}
+
+// Выводит токен из ротации на FloodCooldown после ошибки превышения лимита запросов
+func (p *TokenPool) ReportFlood(key TokenKey) {
+	p.disable(key, p.opts.FloodCooldown)
+}
+
+func (p *TokenPool) disable(key TokenKey, cooldown time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, e := range p.entries {
+		if e.key == key {
+			e.disabledUntil = p.opts.Now().Add(cooldown)
+			return
+		}
+	}
+}
+
+// Возвращает источник, который берет из пула токен с правами s (Acquire)
+// Если API отклонит токен, он выводится из ротации (ReportInvalid)
+func (p *TokenPool) TokenSource(s scope.Scope) TokenSource {
+	return &poolTokenSource{pool: p, acquire: func() (TokenKey, *Token, error) { return p.Acquire(s) }}
+}
+
+// Возвращает источник, который берет из пула токен сообщества groupId (AcquireGroup)
+// Если API отклонит токен, он выводится из ротации (ReportInvalid)
+func (p *TokenPool) GroupTokenSource(groupId int64) TokenSource {
+	return &poolTokenSource{pool: p, acquire: func() (TokenKey, *Token, error) { return p.AcquireGroup(groupId) }}
+}
+
+type poolTokenSource struct {
+	pool    *TokenPool
+	acquire func() (TokenKey, *Token, error)
+}
+
+func (s *poolTokenSource) Token(ctx context.Context) (*Token, error) {
+	_, token, err := s.acquire()
+	return token, err
+}
+
+func (s *poolTokenSource) InvalidateToken(ctx context.Context, token *Token) error {
+	s.pool.mu.Lock()
+	key, found := TokenKey{}, false
+	for _, e := range s.pool.entries {
+		if e.token.AccessToken == token.AccessToken {
+			key, found = e.key, true
+			break
+		}
+	}
+	s.pool.mu.Unlock()
+
+	if found {
+		s.pool.ReportInvalid(key)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package vkoauth_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ciricc/vkoauth"
+	"github.com/ciricc/vkoauth/scope"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestTokenPoolLeastUsed(t *testing.T) {
+	pool := vkoauth.NewTokenPool(vkoauth.TokenPoolOptions{})
+	a := vkoauth.TokenKey{ClientId: "1", UserId: 1}
+	b := vkoauth.TokenKey{ClientId: "1", UserId: 2}
+
+	pool.Add(a, &vkoauth.Token{AccessToken: "a"}, scope.User.Wall|scope.User.Offline)
+	pool.Add(b, &vkoauth.Token{AccessToken: "b"}, scope.User.Wall)
+
+	counts := map[vkoauth.TokenKey]int{}
+	for i := 0; i < 4; i++ {
+		key, _, err := pool.Acquire(scope.User.Wall)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[key]++
+	}
+	if counts[a] != 2 || counts[b] != 2 {
+		t.Errorf("unexpected distribution: %v", counts)
+	}
+
+	key, token, err := pool.Acquire(scope.User.Offline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != a || token.AccessToken != "a" {
+		t.Errorf("unexpected token: %v %+v", key, token)
+	}
+
+	if _, _, err := pool.Acquire(scope.User.Messages); !errors.Is(err, vkoauth.ErrNoTokenAvailable) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestTokenPoolGroups(t *testing.T) {
+	pool := vkoauth.NewTokenPool(vkoauth.TokenPoolOptions{})
+	group := vkoauth.TokenKey{ClientId: "1", GroupId: 10}
+	pool.Add(group, &vkoauth.Token{AccessToken: "group"}, 0)
+
+	key, _, err := pool.AcquireGroup(10)
+	if err != nil || key != group {
+		t.Errorf("unexpected result: %v %v", key, err)
+	}
+
+	if _, _, err := pool.AcquireGroup(20); !errors.Is(err, vkoauth.ErrNoTokenAvailable) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, _, err := pool.Acquire(0); !errors.Is(err, vkoauth.ErrNoTokenAvailable) {
+		t.Errorf("group token returned as user token: %v", err)
+	}
+}
+
+func TestTokenPoolCooldown(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2022, 10, 25, 12, 0, 0, 0, time.UTC)}
+	pool := vkoauth.NewTokenPool(vkoauth.TokenPoolOptions{
+		InvalidCooldown: time.Hour,
+		FloodCooldown:   time.Minute,
+		Now:             clock.Now,
+	})
+	a := vkoauth.TokenKey{ClientId: "1", UserId: 1}
+	b := vkoauth.TokenKey{ClientId: "1", UserId: 2}
+	pool.Add(a, &vkoauth.Token{AccessToken: "a"}, 0)
+	pool.Add(b, &vkoauth.Token{AccessToken: "b"}, 0)
+
+	pool.ReportInvalid(a)
+	pool.ReportFlood(b)
+	if _, _, err := pool.Acquire(0); !errors.Is(err, vkoauth.ErrNoTokenAvailable) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	clock.Add(time.Minute)
+	if key, _, err := pool.Acquire(0); err != nil || key != b {
+		t.Errorf("unexpected result: %v %v", key, err)
+	}
+
+	clock.Add(time.Hour)
+	if key, _, err := pool.Acquire(0); err != nil || key != a {
+		t.Errorf("unexpected result: %v %v", key, err)
+	}
+}
+
+func TestTokenPoolSkipsExpired(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2022, 10, 25, 12, 0, 0, 0, time.UTC)}
+	pool := vkoauth.NewTokenPool(vkoauth.TokenPoolOptions{Now: clock.Now})
+	expires := clock.Now().Add(time.Minute)
+	pool.Add(vkoauth.TokenKey{ClientId: "1", UserId: 1}, &vkoauth.Token{AccessToken: "a", Expires: &expires}, 0)
+
+	if _, _, err := pool.Acquire(0); err != nil {
+		t.Error(err)
+	}
+
+	clock.Add(time.Minute)
+	if _, _, err := pool.Acquire(0); !errors.Is(err, vkoauth.ErrNoTokenAvailable) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestTokenPoolLoad(t *testing.T) {
+	ctx := context.Background()
+	store := vkoauth.NewMemoryTokenStore()
+	store.Put(ctx, vkoauth.TokenKey{ClientId: "1", UserId: 1}, &vkoauth.Token{AccessToken: "a"})
+
+	pool := vkoauth.NewTokenPool(vkoauth.TokenPoolOptions{})
+	if err := pool.Load(ctx, store, scope.User.Wall); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, token, err := pool.Acquire(scope.User.Wall); err != nil || token.AccessToken != "a" {
+		t.Errorf("unexpected result: %+v %v", token, err)
+	}
+}
+
+func TestTokenPoolConcurrent(t *testing.T) {
+	pool := vkoauth.NewTokenPool(vkoauth.TokenPoolOptions{})
+	for i := 1; i <= 5; i++ {
+		pool.Add(vkoauth.TokenKey{ClientId: "1", UserId: int64(i)}, &vkoauth.Token{AccessToken: "token"}, 0)
+	}
+
+	mu := sync.Mutex{}
+	counts := map[vkoauth.TokenKey]int{}
+	wg := sync.WaitGroup{}
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key, _, err := pool.Acquire(0)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			counts[key]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	for key, n := range counts {
+		if n != 20 {
+			t.Errorf("unexpected uses of %s: %d", key, n)
+		}
+	}
+}
+
+func TestTokenPoolAddNil(t *testing.T) {
+	pool := vkoauth.NewTokenPool(vkoauth.TokenPoolOptions{})
+	pool.Add(vkoauth.TokenKey{ClientId: "1", UserId: 1}, nil, scope.User.Wall)
+
+	if _, _, err := pool.Acquire(scope.User.Wall); !errors.Is(err, vkoauth.ErrNoTokenAvailable) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package vkoauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Источник ключа доступа для вызова методов API
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// Функция, реализующая TokenSource
+type TokenSourceFunc func(ctx context.Context) (*Token, error)
+
+func (f TokenSourceFunc) Token(ctx context.Context) (*Token, error) {
+	return f(ctx)
+}
+
+// Возвращает источник с постоянным ключом доступа
+func StaticTokenSource(accessToken string) TokenSource {
+	return TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+		if accessToken == "" {
+			return nil, errors.New("access token is empty")
+		}
+		return &Token{AccessToken: accessToken}, nil
+	})
+}
+
+// Возвращает источник, который получает сервисный ключ доступа через GetServiceToken при каждом вызове
+// Чтобы не запрашивать ключ каждый раз, оберните источник в CachedTokenSource
+func (v *Config) ServiceTokenSource(opts ...AuthOption) TokenSource {
+	return TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+		return v.GetServiceToken(ctx, opts...)
+	})
+}
+
+// Возвращает источник, который запоминает ключ из ts до истечения его срока действия
+// Если API отклонит ключ, он забывается и при следующем вызове запрашивается у ts снова
+// Безопасен для конкурентного использования
+func CachedTokenSource(ts TokenSource) TokenSource {
+	return &cachedTokenSource{ts: ts}
+}
+
+type cachedTokenSource struct {
+	mu    sync.Mutex
+	ts    TokenSource
+	token *Token
+}
+
+func (s *cachedTokenSource) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != nil && !tokenExpired(s.token, time.Now()) {
+		return s.token, nil
+	}
+
+	token, err := s.ts.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.token = token
+	return token, nil
+}
+
+func (s *cachedTokenSource) InvalidateToken(ctx context.Context, token *Token) error {
+	s.mu.Lock()
+	if s.token != nil && s.token.AccessToken == token.AccessToken {
+		s.token = nil
+	}
+	s.mu.Unlock()
+
+	if invalidator, ok := s.ts.(TokenInvalidator); ok {
+		return invalidator.InvalidateToken(ctx, token)
+	}
+	return nil
+}
+
+// Источник ключа доступа, который можно уведомить о том, что API отклонил ключ
+// CallMethod вызывает InvalidateToken, если TokenSource реализует этот интерфейс
+type TokenInvalidator interface {
+	InvalidateToken(ctx context.Context, token *Token) error
+}
+
+// Ошибка, которую возвращает CallMethod, если API отклонил ключ доступа (код 5)
+// Пользователя нужно направить на повторную авторизацию
+type InvalidTokenError struct {
+	Token         *Token    // Отклоненный ключ доступа
+	Err           *ApiError // Ошибка API
+	InvalidateErr error     // Ошибка TokenInvalidator.InvalidateToken (nil, если ключ успешно помечен недействительным)
+}
+
+func (e *InvalidTokenError) Error() string {
+	if e.InvalidateErr != nil {
+		return fmt.Sprintf("access token is invalid: %v (invalidate error: %v)", e.Err, e.InvalidateErr)
+	}
+	return fmt.Sprintf("access token is invalid: %v", e.Err)
+}
+
+func (e *InvalidTokenError) Unwrap() error {
+	return e.Err
+}
+
+// Помечает ключ недействительным в ts и вызывает Config.OnTokenInvalid
+func (v *Config) invalidateToken(ctx context.Context, ts TokenSource, token *Token, apiErr *ApiError) error {
+	e := &InvalidTokenError{Token: token, Err: apiErr}
+	if invalidator, ok := ts.(TokenInvalidator); ok {
+		e.InvalidateErr = invalidator.InvalidateToken(ctx, token)
+	}
+
+	if v.OnTokenInvalid != nil {
+		v.OnTokenInvalid(ctx, e)
+	}
+	return e
+}
+
+// Источник, который берет ключ доступа key из хранилища store
+// Если API отклонит ключ, он удаляется из хранилища (если за это время ключ не был заменен)
+func StoreTokenSource(store TokenStore, key TokenKey) TokenSource {
+	return &storeTokenSource{store: store, key: key}
+}
+
+type storeTokenSource struct {
+	store TokenStore
+	key   TokenKey
+}
+
+func (s *storeTokenSource) Token(ctx context.Context) (*Token, error) {
+	return s.store.Get(ctx, s.key)
+}
+
+func (s *storeTokenSource) InvalidateToken(ctx context.Context, token *Token) error {
+	current, err := s.store.Get(ctx, s.key)
+	if errors.Is(err, ErrTokenNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if current.AccessToken != token.AccessToken {
+		return nil
+	}
+	return s.store.Delete(ctx, s.key)
+}
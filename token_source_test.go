@@ -0,0 +1,116 @@
+package vkoauth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ciricc/vkoauth"
+	"github.com/ciricc/vkoauth/scope"
+)
+
+const authFailedBody = `{"error":{"error_code":5,"error_msg":"User authorization failed: invalid access_token (4)."}}`
+
+func TestStoreTokenSourceInvalidation(t *testing.T) {
+	serv := apiServer(t, map[string]string{"/method/users.get": authFailedBody})
+	defer serv.Close()
+
+	ctx := context.Background()
+	store := vkoauth.NewMemoryTokenStore()
+	key := vkoauth.TokenKey{ClientId: "1", UserId: 1}
+	store.Put(ctx, key, &vkoauth.Token{AccessToken: "USER_TOKEN", UserId: 1})
+
+	events := []*vkoauth.InvalidTokenError{}
+	c := apiConf(serv.URL)
+	c.OnTokenInvalid = func(ctx context.Context, err *vkoauth.InvalidTokenError) {
+		events = append(events, err)
+	}
+
+	err := c.CallMethod(ctx, vkoauth.StoreTokenSource(store, key), "users.get", nil, nil)
+
+	invalidErr := &vkoauth.InvalidTokenError{}
+	if !errors.As(err, &invalidErr) || invalidErr.InvalidateErr != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !errors.Is(err, vkoauth.ErrApiAuthorizationFailed) {
+		t.Errorf("error must wrap ErrApiAuthorizationFailed: %v", err)
+	}
+	if len(events) != 1 || events[0] != invalidErr {
+		t.Errorf("unexpected events: %v", events)
+	}
+	if _, err := store.Get(ctx, key); !errors.Is(err, vkoauth.ErrTokenNotFound) {
+		t.Errorf("token must be deleted from store: %v", err)
+	}
+}
+
+func TestStoreTokenSourceKeepsReplacedToken(t *testing.T) {
+	ctx := context.Background()
+	store := vkoauth.NewMemoryTokenStore()
+	key := vkoauth.TokenKey{ClientId: "1", UserId: 1}
+	store.Put(ctx, key, &vkoauth.Token{AccessToken: "NEW_TOKEN", UserId: 1})
+
+	ts := vkoauth.StoreTokenSource(store, key).(vkoauth.TokenInvalidator)
+	if err := ts.InvalidateToken(ctx, &vkoauth.Token{AccessToken: "OLD_TOKEN"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, key); err != nil {
+		t.Errorf("replaced token must be kept: %v", err)
+	}
+}
+
+func TestPoolTokenSourceInvalidation(t *testing.T) {
+	serv := apiServer(t, map[string]string{"/method/users.get": authFailedBody})
+	defer serv.Close()
+
+	pool := vkoauth.NewTokenPool(vkoauth.TokenPoolOptions{})
+	pool.Add(vkoauth.TokenKey{ClientId: "1", UserId: 1}, &vkoauth.Token{AccessToken: "USER_TOKEN"}, scope.User.Wall)
+
+	c := apiConf(serv.URL)
+	err := c.CallMethod(context.Background(), pool.TokenSource(scope.User.Wall), "users.get", nil, nil)
+
+	invalidErr := &vkoauth.InvalidTokenError{}
+	if !errors.As(err, &invalidErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err := pool.Acquire(scope.User.Wall); !errors.Is(err, vkoauth.ErrNoTokenAvailable) {
+		t.Errorf("token must be removed from rotation: %v", err)
+	}
+}
+
+func TestOtherApiErrorsNotInvalidated(t *testing.T) {
+	serv := apiServer(t, map[string]string{
+		"/method/users.get": `{"error":{"error_code":15,"error_msg":"Access denied"}}`,
+	})
+	defer serv.Close()
+
+	c := apiConf(serv.URL)
+	c.OnTokenInvalid = func(ctx context.Context, err *vkoauth.InvalidTokenError) {
+		t.Errorf("unexpected event: %v", err)
+	}
+
+	err := c.CallMethod(context.Background(), vkoauth.StaticTokenSource("USER_TOKEN"), "users.get", nil, nil)
+	if _, ok := err.(*vkoauth.ApiError); !ok {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCachedTokenSource(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	ts := vkoauth.CachedTokenSource(vkoauth.TokenSourceFunc(func(ctx context.Context) (*vkoauth.Token, error) {
+		calls++
+		return &vkoauth.Token{AccessToken: "TOKEN"}, nil
+	}))
+
+	ts.Token(ctx)
+	token, err := ts.Token(ctx)
+	if err != nil || token.AccessToken != "TOKEN" || calls != 1 {
+		t.Errorf("unexpected result: %+v %v, calls %d", token, err, calls)
+	}
+
+	ts.(vkoauth.TokenInvalidator).InvalidateToken(ctx, token)
+	ts.Token(ctx)
+	if calls != 2 {
+		t.Errorf("token must be requested again after invalidation, calls %d", calls)
+	}
+}
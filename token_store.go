@@ -0,0 +1,216 @@
+package vkoauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Ошибка, которую возвращает TokenStore, если токен не найден
+var ErrTokenNotFound = errors.New("token not found")
+
+// Тип токена
+type TokenKind string
+
+const TokenKindUser TokenKind = "user"       // Токен пользователя
+const TokenKindGroup TokenKind = "group"     // Токен сообщества
+const TokenKindService TokenKind = "service" // Сервисный ключ приложения
+
+// Ключ, по которому токен хранится в TokenStore
+type TokenKey struct {
+	ClientId string // Идентификатор приложения, выдавшего токен
+	UserId   int64  // Идентификатор пользователя (0 для токенов сообществ и сервисных ключей)
+	GroupId  int64  // Идентификатор сообщества (0 для токенов пользователей и сервисных ключей)
+}
+
+// Возвращает тип токена, который хранится по ключу
+func (k TokenKey) Kind() TokenKind {
+	if k.GroupId != 0 {
+		return TokenKindGroup
+	}
+	if k.UserId != 0 {
+		return TokenKindUser
+	}
+	return TokenKindService
+}
+
+func (k TokenKey) String() string {
+	switch k.Kind() {
+	case TokenKindGroup:
+		return fmt.Sprintf("%s/group/%d", k.ClientId, k.GroupId)
+	case TokenKindUser:
+		return fmt.Sprintf("%s/user/%d", k.ClientId, k.UserId)
+	}
+	return fmt.Sprintf("%s/service", k.ClientId)
+}
+
+// Хранилище токенов
+// Реализации должны быть безопасны для конкурентного использования
+type TokenStore interface {
+	// Возвращает токен по ключу или ErrTokenNotFound
+	Get(ctx context.Context, key TokenKey) (*Token, error)
+	// Сохраняет токен по ключу, заменяя предыдущее значение
+	Put(ctx context.Context, key TokenKey, token *Token) error
+	// Удаляет токен по ключу, отсутствие токена не является ошибкой
+	Delete(ctx context.Context, key TokenKey) error
+	// Возвращает ключи всех сохраненных токенов
+	List(ctx context.Context) ([]TokenKey, error)
+	// Удаляет токены, срок действия которых истек к моменту now, и возвращает их количество
+	Prune(ctx context.Context, now time.Time) (int, error)
+}
+
+// Сохраняет результат авторизации в хранилище
+// Токен пользователя (или сервисный ключ) и токены сообществ сохраняются под отдельными ключами
+func StoreToken(ctx context.Context, store TokenStore, clientId string, token *Token) error {
+	if token == nil {
+		return fmt.Errorf("token is nil")
+	}
+
+	if token.AccessToken != "" {
+		userToken := cloneToken(token)
+		userToken.Groups = nil
+		err := store.Put(ctx, TokenKey{ClientId: clientId, UserId: token.UserId}, userToken)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, g := range token.Groups {
+		if g == nil {
+			continue
+		}
+		groupToken := &Token{
+			AccessToken: g.AccessToken,
+			Expires:     token.Expires,
+			State:       token.State,
+		}
+		err := store.Put(ctx, TokenKey{ClientId: clientId, GroupId: g.GroupId}, groupToken)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Хранилище токенов в памяти
+type MemoryTokenStore struct {
+	mu     sync.RWMutex
+	tokens map[TokenKey]*Token
+}
+
+// Создает пустое хранилище токенов в памяти
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{
+		tokens: make(map[TokenKey]*Token),
+	}
+}
+
+func (s *MemoryTokenStore) Get(ctx context.Context, key TokenKey) (*Token, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	token, ok := s.tokens[key]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	return cloneToken(token), nil
+}
+
+func (s *MemoryTokenStore) Put(ctx context.Context, key TokenKey, token *Token) error {
+	if token == nil {
+		return fmt.Errorf("token is nil")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tokens == nil {
+		s.tokens = make(map[TokenKey]*Token)
+	}
+	s.tokens[key] = cloneToken(token)
+	return nil
+}
+
+func (s *MemoryTokenStore) Delete(ctx context.Context, key TokenKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tokens, key)
+	return nil
+}
+
+func (s *MemoryTokenStore) List(ctx context.Context) ([]TokenKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]TokenKey, 0, len(s.tokens))
+	for k := range s.tokens {
+		keys = append(keys, k)
+	}
+	sortTokenKeys(keys)
+	return keys, nil
+}
+
+func (s *MemoryTokenStore) Prune(ctx context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for k, token := range s.tokens {
+		if tokenExpired(token, now) {
+			delete(s.tokens, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Возвращает true, если срок действия токена истек к моменту now
+func tokenExpired(token *Token, now time.Time) bool {
+	return token.Expires != nil && !token.Expires.After(now)
+}
+
+func sortTokenKeys(keys []TokenKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].ClientId != keys[j].ClientId {
+			return keys[i].ClientId < keys[j].ClientId
+		}
+		if keys[i].UserId != keys[j].UserId {
+			return keys[i].UserId < keys[j].UserId
+		}
+		return keys[i].GroupId < keys[j].GroupId
+	})
+}
+
+// Возвращает копию токена, не разделяющую с оригиналом изменяемые поля
+func cloneToken(token *Token) *Token {
+	c := *token
+
+	if token.Expires != nil {
+		e := *token.Expires
+		c.Expires = &e
+	}
+
+	if token.Groups != nil {
+		c.Groups = make([]*GroupToken, len(token.Groups))
+		for i, g := range token.Groups {
+			if g != nil {
+				gc := *g
+				c.Groups[i] = &gc
+			}
+		}
+	}
+
+	if token.Raw != nil {
+		c.Raw = make(map[string]interface{}, len(token.Raw))
+		for k, v := range token.Raw {
+			c.Raw[k] = v
+		}
+	}
+
+	return &c
+}
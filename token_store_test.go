@@ -0,0 +1,206 @@
+package vkoauth_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ciricc/vkoauth"
+)
+
+func testTokenStore(t *testing.T, store vkoauth.TokenStore) {
+	ctx := context.Background()
+	now := time.Date(2022, 10, 25, 12, 0, 0, 0, time.UTC)
+	expired := now.Add(-time.Hour)
+	valid := now.Add(time.Hour)
+
+	userKey := vkoauth.TokenKey{ClientId: "2274003", UserId: 66748}
+	groupKey := vkoauth.TokenKey{ClientId: "2274003", GroupId: 123456}
+	serviceKey := vkoauth.TokenKey{ClientId: "2274003"}
+
+	if _, err := store.Get(ctx, userKey); !errors.Is(err, vkoauth.ErrTokenNotFound) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	userToken := &vkoauth.Token{AccessToken: "user", UserId: 66748, Expires: &valid}
+	if err := store.Put(ctx, userKey, userToken); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, groupKey, &vkoauth.Token{AccessToken: "group", Expires: &expired}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, serviceKey, &vkoauth.Token{AccessToken: "service"}); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := store.Get(ctx, userKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "user" || token.UserId != 66748 || token.Expires == nil || !token.Expires.Equal(valid) {
+		t.Errorf("unexpected token: %+v", token)
+	}
+
+	keys, err := store.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, []vkoauth.TokenKey{serviceKey, groupKey, userKey}) {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+
+	n, err := store.Prune(ctx, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("unexpected pruned count: %d", n)
+	}
+	if _, err := store.Get(ctx, groupKey); !errors.Is(err, vkoauth.ErrTokenNotFound) {
+		t.Errorf("expired token is not pruned: %v", err)
+	}
+
+	if err := store.Delete(ctx, userKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ctx, userKey); err != nil {
+		t.Errorf("unexpected error on repeated delete: %v", err)
+	}
+
+	keys, err = store.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, []vkoauth.TokenKey{serviceKey}) {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+}
+
+func TestMemoryTokenStore(t *testing.T) {
+	testTokenStore(t, vkoauth.NewMemoryTokenStore())
+}
+
+func TestMemoryTokenStoreCopiesTokens(t *testing.T) {
+	ctx := context.Background()
+	store := vkoauth.NewMemoryTokenStore()
+	key := vkoauth.TokenKey{ClientId: "1", UserId: 1}
+
+	token := &vkoauth.Token{AccessToken: "original"}
+	store.Put(ctx, key, token)
+	token.AccessToken = "changed"
+
+	stored, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.AccessToken != "original" {
+		t.Errorf("unexpected access token: %q", stored.AccessToken)
+	}
+}
+
+func TestMemoryTokenStoreConcurrent(t *testing.T) {
+	ctx := context.Background()
+	store := vkoauth.NewMemoryTokenStore()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := vkoauth.TokenKey{ClientId: "1", UserId: int64(i + 1)}
+			store.Put(ctx, key, &vkoauth.Token{AccessToken: "token"})
+			store.Get(ctx, key)
+			store.List(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	keys, _ := store.List(ctx)
+	if len(keys) != 50 {
+		t.Errorf("unexpected keys count: %d", len(keys))
+	}
+}
+
+func TestFileTokenStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	testTokenStore(t, vkoauth.NewFileTokenStore(path))
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("unexpected file mode: %v", info.Mode())
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("unexpected files in store directory: %v", entries)
+	}
+}
+
+func TestFileTokenStorePersists(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	key := vkoauth.TokenKey{ClientId: "1", UserId: 1}
+
+	if err := vkoauth.NewFileTokenStore(path).Put(ctx, key, &vkoauth.Token{AccessToken: "token", UserId: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := vkoauth.NewFileTokenStore(path).Get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "token" {
+		t.Errorf("unexpected token: %+v", token)
+	}
+}
+
+func TestStoreToken(t *testing.T) {
+	ctx := context.Background()
+	store := vkoauth.NewMemoryTokenStore()
+
+	err := vkoauth.StoreToken(ctx, store, "2274003", &vkoauth.Token{
+		AccessToken: "user",
+		UserId:      66748,
+		Groups: []*vkoauth.GroupToken{
+			{GroupId: 123456, AccessToken: "group"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, _ := store.List(ctx)
+	expected := []vkoauth.TokenKey{
+		{ClientId: "2274003", GroupId: 123456},
+		{ClientId: "2274003", UserId: 66748},
+	}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+
+	if keys[0].Kind() != vkoauth.TokenKindGroup || keys[1].Kind() != vkoauth.TokenKindUser {
+		t.Errorf("unexpected kinds: %v %v", keys[0].Kind(), keys[1].Kind())
+	}
+
+	groupToken, _ := store.Get(ctx, keys[0])
+	if groupToken.AccessToken != "group" {
+		t.Errorf("unexpected group token: %+v", groupToken)
+	}
+}
+
+func TestStoreTokenNil(t *testing.T) {
+	if err := vkoauth.StoreToken(context.Background(), vkoauth.NewMemoryTokenStore(), "2274003", nil); err == nil {
+		t.Errorf("expected error")
+	}
+}
@@ -2,6 +2,7 @@ package vkoauth
 
 import (
 	"context"
+	"io"
 	"net/http"
 )
 
@@ -18,3 +19,67 @@ func ContextClient(ctx context.Context) *http.Client {
 	}
 	return http.DefaultClient
 }
+
+type headerKey struct{}
+
+// Возвращает контекст, с которым к запросам библиотеки (получение токена, вызов методов API)
+// добавляется заголовок key: value
+// Значения добавляются к заголовкам, указанным ранее в родительском контексте
+// Если ctx равен nil, используется context.Background()
+func WithHeader(ctx context.Context, key, value string) context.Context {
+	h := ContextHeader(ctx)
+	h.Add(key, value)
+	return withHeader(ctx, h)
+}
+
+// Возвращает контекст, с которым запросы библиотеки отправляются с заголовком User-Agent
+func WithUserAgent(ctx context.Context, userAgent string) context.Context {
+	return setContextHeader(ctx, "User-Agent", userAgent)
+}
+
+// Возвращает контекст, с которым запросы библиотеки отправляются с заголовком Accept-Language
+func WithAcceptLanguage(ctx context.Context, lang string) context.Context {
+	return setContextHeader(ctx, "Accept-Language", lang)
+}
+
+// Возвращает копию заголовков, добавленных в контекст с помощью WithHeader, WithUserAgent и WithAcceptLanguage
+func ContextHeader(ctx context.Context) http.Header {
+	if ctx != nil {
+		if h, ok := ctx.Value(headerKey{}).(http.Header); ok {
+			return h.Clone()
+		}
+	}
+	return http.Header{}
+}
+
+func setContextHeader(ctx context.Context, key, value string) context.Context {
+	h := ContextHeader(ctx)
+	h.Set(key, value)
+	return withHeader(ctx, h)
+}
+
+func withHeader(ctx context.Context, h http.Header) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, headerKey{}, h)
+}
+
+// Создает запрос и добавляет к нему заголовки из контекста
+// Заголовки из контекста заменяют одноименные заголовки, которые выставляет http.Client (например, User-Agent)
+// Если ctx равен nil, используется context.Background()
+func newContextRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, vals := range ContextHeader(ctx) {
+		req.Header[k] = vals
+	}
+	return req, nil
+}
@@ -3,6 +3,7 @@ package vkoauth_test
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/ciricc/vkoauth"
@@ -33,3 +34,78 @@ func TestContextClientInvalidType(t *testing.T) {
 		t.Errorf("unexpected client: %v", vkoauth.ContextClient(ctx))
 	}
 }
+
+func TestContextHeader(t *testing.T) {
+	parent := vkoauth.WithHeader(context.Background(), "X-Request-Id", "1")
+	ctx := vkoauth.WithHeader(parent, "X-Request-Id", "2")
+	ctx = vkoauth.WithUserAgent(ctx, "app/1.0")
+	ctx = vkoauth.WithUserAgent(ctx, "app/2.0")
+
+	h := vkoauth.ContextHeader(ctx)
+	if len(h.Values("X-Request-Id")) != 2 || h.Get("User-Agent") != "app/2.0" {
+		t.Errorf("unexpected headers: %v", h)
+	}
+	if len(vkoauth.ContextHeader(parent)) != 1 {
+		t.Errorf("parent headers must not be modified: %v", vkoauth.ContextHeader(parent))
+	}
+}
+
+func TestContextHeaderRequests(t *testing.T) {
+	headers := []http.Header{}
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Clone())
+		if r.URL.Path == "/access_token" {
+			w.Write([]byte(`{"access_token":"SERVICE_TOKEN"}`))
+			return
+		}
+		w.Write([]byte(`{"response":1}`))
+	}))
+	defer serv.Close()
+
+	ctx := vkoauth.WithUserAgent(context.Background(), "app/1.0")
+	ctx = vkoauth.WithAcceptLanguage(ctx, "en")
+	ctx = vkoauth.WithHeader(ctx, "X-Request-Id", "42")
+	ctx = vkoauth.WithHeader(ctx, "Content-Type", "text/plain")
+
+	c := apiConf(serv.URL)
+	if _, err := c.GetServiceToken(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.CallMethod(ctx, vkoauth.StaticTokenSource("USER_TOKEN"), "users.get", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(headers) != 2 {
+		t.Fatalf("unexpected requests count: %d", len(headers))
+	}
+	for _, h := range headers {
+		if h.Get("User-Agent") != "app/1.0" || h.Get("Accept-Language") != "en" || h.Get("X-Request-Id") != "42" {
+			t.Errorf("unexpected headers: %v", h)
+		}
+		if h.Get("Content-Type") != "application/x-www-form-urlencoded; charset=utf-8" {
+			t.Errorf("unexpected content type: %q", h.Get("Content-Type"))
+		}
+	}
+}
+
+func TestNilContextRequests(t *testing.T) {
+	serv := apiServer(t, map[string]string{"/method/users.get": `{"response":1}`})
+	defer serv.Close()
+
+	c := apiConf(serv.URL)
+	if err := c.CallMethod(nil, vkoauth.StaticTokenSource("USER_TOKEN"), "users.get", nil, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestContextHeaderNilParent(t *testing.T) {
+	for _, ctx := range []context.Context{
+		vkoauth.WithHeader(nil, "X-Request-Id", "1"),
+		vkoauth.WithUserAgent(nil, "app/1.0"),
+		vkoauth.WithAcceptLanguage(nil, "en"),
+	} {
+		if len(vkoauth.ContextHeader(ctx)) != 1 {
+			t.Errorf("unexpected headers: %v", vkoauth.ContextHeader(ctx))
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package vkoauth
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var clientIdRegexp = regexp.MustCompile(`^[0-9]+$`)
+var versionRegexp = regexp.MustCompile(`^[0-9]+\.[0-9]+$`)
+
+// Проблема в одном поле конфигурации
+type FieldError struct {
+	Field   string // Название поля Config
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// Ошибка проверки конфигурации, содержит все найденные проблемы
+type ValidationError struct {
+	Flow   Flow
+	Fields []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		problems[i] = f.Error()
+	}
+	return fmt.Sprintf("invalid config for %s flow: %s", e.Flow, strings.Join(problems, "; "))
+}
+
+// Проверяет, что в конфигурации заполнено все, что нужно для авторизации способом flow:
+//   - FlowImplicit (ImplicitFlowAuthUrl): ClientId, RedirectUri, Endpoint.AuthUrl
+//   - FlowCode (CodeFlowAuthUrl, ExchangeCode): ClientId, ClientSecret, RedirectUri, Endpoint.AuthUrl, Endpoint.TokenUrl
+//   - FlowClientCredentials (GetServiceToken): ClientId, ClientSecret, Endpoint.TokenUrl
+//   - FlowPassword (PasswordCredentials) и FlowExtendSid (ExtendSid): ClientId, ClientSecret, Endpoint.PasswordTokenUrl
+//
+// Серверу, который только создает ссылку CodeFlowAuthUrl (без ExchangeCode), секретный ключ и TokenUrl не нужны:
+// проверяйте его конфигурацию с FlowImplicit, набор полей для ссылки авторизации совпадает
+//
+// Для всех способов проверяется формат ClientId (число) и Version (вида 5.131),
+// а также формат указанных адресов запасных наборов FallbackEndpoints, которые используются способом flow
+// Возвращает *ValidationError со всеми найденными проблемами или nil
+func (v *Config) Validate(flow Flow) error {
+	e := &ValidationError{Flow: flow}
+	add := func(field, format string, args ...interface{}) {
+		e.Fields = append(e.Fields, &FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var needSecret, needRedirect, needAuthUrl, needTokenUrl, needPasswordUrl bool
+	switch flow {
+	case FlowImplicit:
+		needRedirect, needAuthUrl = true, true
+	case FlowCode:
+		needSecret, needRedirect, needAuthUrl, needTokenUrl = true, true, true, true
+	case FlowClientCredentials:
+		needSecret, needTokenUrl = true, true
+	case FlowPassword, FlowExtendSid:
+		needSecret, needPasswordUrl = true, true
+	default:
+		add("Flow", "unknown flow %s", flow)
+		return e
+	}
+
+	if v.ClientId == "" {
+		add("ClientId", "is required")
+	} else if !clientIdRegexp.MatchString(v.ClientId) {
+		add("ClientId", "must be numeric, got %q", v.ClientId)
+	}
+
+	if needSecret && v.ClientSecret == "" && v.SecretSource == nil {
+		add("ClientSecret", "is required")
+	}
+
+	if v.Version != "" && !versionRegexp.MatchString(v.Version) {
+		add("Version", "must look like 5.131, got %q", v.Version)
+	}
+
+	if needRedirect {
+		if v.RedirectUri == "" {
+			add("RedirectUri", "is required")
+		} else if msg := checkHttpUrl(v.RedirectUri); msg != "" {
+			add("RedirectUri", "%s", msg)
+		}
+	}
+
+	endpoint := v.endpoint()
+	endpointUrls := []struct {
+		need  bool
+		field string
+		value string
+	}{
+		{needAuthUrl, "Endpoint.AuthUrl", endpoint.AuthUrl},
+		{needTokenUrl, "Endpoint.TokenUrl", endpoint.TokenUrl},
+		{needPasswordUrl, "Endpoint.PasswordTokenUrl", endpoint.PasswordTokenUrl},
+	}
+
+	for _, u := range endpointUrls {
+		if !u.need {
+			continue
+		}
+		if u.value == "" {
+			add(u.field, "is required")
+		} else if msg := checkHttpUrl(u.value); msg != "" {
+			add(u.field, "%s", msg)
+		}
+	}
+
+	for i, fallback := range v.FallbackEndpoints {
+		if fallback == nil {
+			continue
+		}
+
+		fallbackUrls := []struct {
+			need  bool
+			field string
+			value string
+		}{
+			{needTokenUrl, "TokenUrl", fallback.TokenUrl},
+			{needPasswordUrl, "PasswordTokenUrl", fallback.PasswordTokenUrl},
+		}
+
+		for _, u := range fallbackUrls {
+			if !u.need || u.value == "" {
+				continue
+			}
+			if msg := checkHttpUrl(u.value); msg != "" {
+				add(fmt.Sprintf("FallbackEndpoints[%d].%s", i, u.field), "%s", msg)
+			}
+		}
+	}
+
+	if len(e.Fields) > 0 {
+		return e
+	}
+	return nil
+}
+
+// Возвращает описание проблемы, если rawUrl - не абсолютный http(s) URL
+func checkHttpUrl(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return fmt.Sprintf("invalid url %q: %v", rawUrl, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Sprintf("url %q must use http or https scheme", rawUrl)
+	}
+	if u.Host == "" {
+		return fmt.Sprintf("url %q must contain host", rawUrl)
+	}
+	return ""
+}
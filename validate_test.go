@@ -0,0 +1,128 @@
+package vkoauth_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ciricc/vkoauth"
+)
+
+func validationFields(err error) []string {
+	validationErr := &vkoauth.ValidationError{}
+	if !errors.As(err, &validationErr) {
+		return nil
+	}
+
+	fields := []string{}
+	for _, f := range validationErr.Fields {
+		fields = append(fields, f.Field)
+	}
+	return fields
+}
+
+func TestValidate(t *testing.T) {
+	valid := vkoauth.Config{
+		ClientId:     "2274003",
+		ClientSecret: "secret",
+		Version:      "5.131",
+		RedirectUri:  "https://example.com/callback",
+	}
+
+	for _, flow := range []vkoauth.Flow{vkoauth.FlowImplicit, vkoauth.FlowCode, vkoauth.FlowClientCredentials, vkoauth.FlowPassword, vkoauth.FlowExtendSid} {
+		if err := valid.Validate(flow); err != nil {
+			t.Errorf("unexpected error for %s flow: %v", flow, err)
+		}
+	}
+
+	cases := []struct {
+		Config vkoauth.Config
+		Flow   vkoauth.Flow
+		Fields []string
+	}{
+		{
+			Config: vkoauth.Config{ClientId: "2274003", RedirectUri: "https://oauth.vk.com/blank.html"},
+			Flow:   vkoauth.FlowImplicit,
+		},
+		{
+			Config: vkoauth.Config{},
+			Flow:   vkoauth.FlowCode,
+			Fields: []string{"ClientId", "ClientSecret", "RedirectUri"},
+		},
+		{
+			Config: vkoauth.Config{ClientId: "app", ClientSecret: "secret", Version: "5", RedirectUri: "blank.html"},
+			Flow:   vkoauth.FlowImplicit,
+			Fields: []string{"ClientId", "Version", "RedirectUri"},
+		},
+		{
+			Config: vkoauth.Config{ClientId: "1", RedirectUri: "ftp://example.com"},
+			Flow:   vkoauth.FlowClientCredentials,
+			Fields: []string{"ClientSecret"},
+		},
+		{
+			Config: vkoauth.Config{ClientId: "1", ClientSecret: "secret", Endpoint: &vkoauth.Endpoint{TokenUrl: "https://oauth.vk.com/access_token"}},
+			Flow:   vkoauth.FlowPassword,
+			Fields: []string{"Endpoint.PasswordTokenUrl"},
+		},
+		{
+			Config: vkoauth.Config{ClientId: "1", ClientSecret: "secret", RedirectUri: "https://example.com", Endpoint: &vkoauth.Endpoint{AuthUrl: "/authorize", TokenUrl: "https://oauth.vk.com/access_token"}},
+			Flow:   vkoauth.FlowCode,
+			Fields: []string{"Endpoint.AuthUrl"},
+		},
+		{
+			Config: valid,
+			Flow:   vkoauth.Flow(100),
+			Fields: []string{"Flow"},
+		},
+	}
+
+	for _, c := range cases {
+		err := c.Config.Validate(c.Flow)
+		if c.Fields == nil {
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			continue
+		}
+
+		if fields := validationFields(err); !reflect.DeepEqual(fields, c.Fields) {
+			t.Errorf("unexpected fields for %s flow: %v (%v)", c.Flow, fields, err)
+		}
+	}
+}
+
+func TestValidationErrorMessage(t *testing.T) {
+	c := vkoauth.Config{ClientId: "app"}
+	err := c.Validate(vkoauth.FlowClientCredentials)
+	if err == nil || err.Error() != `invalid config for client credentials flow: ClientId: must be numeric, got "app"; ClientSecret: is required` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateFallbackEndpoints(t *testing.T) {
+	c := vkoauth.Config{
+		ClientId:     "2274003",
+		ClientSecret: "secret",
+		FallbackEndpoints: []*vkoauth.Endpoint{
+			vkoauth.VkRuEndpoint,
+			{TokenUrl: "oauth.vk.ru/access_token", PasswordTokenUrl: "ftp://oauth.vk.ru/token"},
+			nil,
+		},
+	}
+
+	fields := validationFields(c.Validate(vkoauth.FlowClientCredentials))
+	if !reflect.DeepEqual(fields, []string{"FallbackEndpoints[1].TokenUrl"}) {
+		t.Errorf("unexpected fields: %v", fields)
+	}
+
+	fields = validationFields(c.Validate(vkoauth.FlowPassword))
+	if !reflect.DeepEqual(fields, []string{"FallbackEndpoints[1].PasswordTokenUrl"}) {
+		t.Errorf("unexpected fields: %v", fields)
+	}
+
+	// Запасные адреса без нужного способу адреса пропускаются
+	c.FallbackEndpoints = []*vkoauth.Endpoint{vkoauth.VkIdEndpoint}
+	if err := c.Validate(vkoauth.FlowPassword); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
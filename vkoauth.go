@@ -3,8 +3,9 @@ package vkoauth
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
@@ -13,29 +14,43 @@ import (
 	"github.com/ciricc/vkoauth/scope"
 )
 
-var DefaultVersion = "5.131" // Версия API ВКонтакте по умолчанию
-var DefaultVkEndpoint = &Endpoint{
-	AuthUrl:          "https://oauth.vk.com/authorize",
-	PasswordTokenUrl: "https://oauth.vk.com/token",
-	TokenUrl:         "https://oauth.vk.com/access_token",
-} // Конфигурация API ВКонтакте по умолчанию
+var DefaultVersion = "5.131"          // Версия API ВКонтакте по умолчанию
+var DefaultVkEndpoint = VkComEndpoint // Конфигурация API ВКонтакте по умолчанию
 
 type Endpoint struct {
 	AuthUrl          string // URL страницы, на которой будет проходить авторизация пользователя
 	PasswordTokenUrl string // URL страницы, на которую будет отправляться запрос на получение токена по логину и пароля
 	TokenUrl         string // URL страницы, на которую будет отправляться запрос на получение токена после прохождения аутентификации
+	ApiUrl           string // Базовый URL методов API (https://api.vk.com/method), если не указан - берется из DefaultVkEndpoint
 }
 
+// Ошибка, которую возвращают методы создания URL авторизации, если права пользователя
+// используются при авторизации сообществ (или наоборот)
+var ErrMixedScope = errors.New("user and community scopes are mixed")
+
 // Конфигурация OAuth
 // Для указания scope, используйте побитовое сложение (scope.User.photos | scope.User.Wall)
 // или используйте значение scope.User.All, чтобы запросить все права
+// Права сообществ (scope.Group) указываются отдельно в GroupScope и используются, только если заданы AuthParams.GroupIds
 type Config struct {
-	ClientId     string // Идентификатор приложения
-	ClientSecret string // Секретный ключ приложения
+	ClientId     string       // Идентификатор приложения
+	ClientSecret string       // Секретный ключ приложения
+	SecretSource SecretSource // Источник секретного ключа, если указан - используется вместо ClientSecret
+	// Источник сервисного ключа для CheckToken, например CachedTokenSource(c.ServiceTokenSource())
+	// Если не указан, ключ запрашивается через GetServiceToken при каждой проверке
+	ServiceToken TokenSource
 	Version      string // Версия API ВК
 	Endpoint     *Endpoint
-	Scope        scope.Scope // Права доступа
-	RedirectUri  string      // Ссылка, на которую будет перенаправлен пользователь после успешного прохождения аутентификации
+	// Запасные наборы адресов, на которые повторяется запрос токена
+	// при ошибке соединения или ответе 5xx (например, VkRuEndpoint)
+	// Наборы VK ID и oauth.vk.com не смешиваются: запасные наборы другого протокола пропускаются
+	FallbackEndpoints []*Endpoint
+	Scope             scope.Scope      // Права доступа пользователя
+	GroupScope        scope.GroupScope // Права доступа сообществ
+	RedirectUri       string           // Ссылка, на которую будет перенаправлен пользователь после успешного прохождения аутентификации
+	// Вызывается, когда API отклонил ключ доступа (код 5) и ключ помечен недействительным
+	// Используйте, чтобы направить пользователя на повторную авторизацию
+	OnTokenInvalid func(ctx context.Context, err *InvalidTokenError)
 }
 
 type GroupToken struct {
@@ -61,17 +76,34 @@ func (v *Config) endpoint() *Endpoint {
 }
 
 // Создает URL, на который нужно направить пользователя для проведения авторизации
-func (v *Config) buildAuthUrl(params AuthParams, responseType string, opts ...AuthOption) string {
+// Возвращает ErrMixedScope, если для авторизации сообществ указаны только права пользователя (или наоборот),
+// и *display.InvalidError, если указан неизвестный стиль страницы авторизации
+func (v *Config) buildAuthUrl(params AuthParams, responseType string, opts ...AuthOption) (string, error) {
 	u := url.Values{}
 
 	if params.Display != "" {
+		if err := params.Display.Validate(); err != nil {
+			return "", err
+		}
 		u.Set("display", string(params.Display))
 	}
 
 	u.Set("v", v.version())
 
-	if v.Scope != 0 {
-		u.Set("scope", strconv.FormatInt(int64(v.Scope), 10))
+	if len(params.GroupIds) > 0 {
+		if v.GroupScope == 0 && v.Scope != 0 {
+			return "", fmt.Errorf("%w: user scope %q requested in community authorization", ErrMixedScope, v.Scope)
+		}
+		if v.GroupScope != 0 {
+			u.Set("scope", strconv.FormatInt(int64(v.GroupScope), 10))
+		}
+	} else {
+		if v.Scope == 0 && v.GroupScope != 0 {
+			return "", fmt.Errorf("%w: community scope %q requested without group ids", ErrMixedScope, v.GroupScope)
+		}
+		if v.Scope != 0 {
+			u.Set("scope", strconv.FormatInt(int64(v.Scope), 10))
+		}
 	}
 
 	u.Set("redirect_uri", v.RedirectUri)
@@ -86,6 +118,10 @@ func (v *Config) buildAuthUrl(params AuthParams, responseType string, opts ...Au
 		u.Set("revoke", "1")
 	}
 
+	if params.Lang != "" {
+		u.Set("lang", params.Lang)
+	}
+
 	if len(params.GroupIds) > 0 {
 		idsStrings := make([]string, len(params.GroupIds))
 		for i, id := range params.GroupIds {
@@ -109,7 +145,7 @@ func (v *Config) buildAuthUrl(params AuthParams, responseType string, opts ...Au
 	}
 
 	uri += u.Encode()
-	return uri
+	return uri, nil
 }
 
 // Делает запрос на получение токена по указанному URL
@@ -125,45 +161,43 @@ func (v *Config) doTokenRequest(ctx context.Context, reqUrl string) (*Token, err
 	}
 
 	rawQ := url.RawQuery
+	lang := url.Query().Get("lang")
 	url.RawQuery = ""
 
-	res, err := client.Post(url.String(), "application/x-www-form-urlencoded; charset=utf-8", strings.NewReader(rawQ))
+	req, err := newContextRequest(ctx, http.MethodPost, url.String(), strings.NewReader(rawQ))
 	if err != nil {
 		return nil, err
 	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
 
-	b, err := io.ReadAll(io.NopCloser(res.Body))
+	res, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 
-	res.Body.Close()
-	if code := res.StatusCode; code < 200 || code > 299 {
-
-		tokenErrorJson := TokenErrorJson{}
-		json.Unmarshal(b, &tokenErrorJson)
+	b, err := readResponse(res)
+	if err != nil {
+		return nil, err
+	}
 
-		return nil, &TokenError{
-			Response:         res,
-			Body:             b,
-			RedirectURI:      tokenErrorJson.RedirectURI,
-			ErrorCode:        tokenErrorJson.Error,
-			ValidationType:   tokenErrorJson.ValidationType,
-			ValidationSid:    tokenErrorJson.ValidationSid,
-			PhoneMask:        tokenErrorJson.PhoneMask,
-			ValidationResend: tokenErrorJson.ValidationResend,
-			CaptchaSid:       tokenErrorJson.CaptchaSid,
-			CaptchaImg:       tokenErrorJson.CaptchaImg,
-			description:      tokenErrorJson.ErrorDescription,
-			ErrorType:        tokenErrorJson.ErrorType,
+	tokenErrorJson := TokenErrorJson{}
+	if code := res.StatusCode; code < 200 || code > 299 {
+		if err := json.Unmarshal(b, &tokenErrorJson); err != nil {
+			return nil, newResponseError(res, b, err, "parse token error response")
 		}
+		return nil, newTokenError(res, b, tokenErrorJson, lang)
+	}
+
+	// Сервер может вернуть ошибку и с кодом 200
+	if json.Unmarshal(b, &tokenErrorJson) == nil && (tokenErrorJson.Error != "" || tokenErrorJson.ErrorDescription != "") {
+		return nil, newTokenError(res, b, tokenErrorJson, lang)
 	}
 
 	tokenJson := AccessTokenJson{}
 
 	err = json.Unmarshal(b, &tokenJson)
 	if err != nil {
-		return nil, err
+		return nil, newResponseError(res, b, err, "parse token response")
 	}
 
 	token := &Token{
@@ -184,9 +218,31 @@ func (v *Config) doTokenRequest(ctx context.Context, reqUrl string) (*Token, err
 		}
 	}
 
+	if !token.hasAccessToken() {
+		return nil, &TokenError{
+			Response:    res,
+			Body:        b,
+			ErrorCode:   ErrorCodeEmptyToken,
+			description: "access token is missing in response",
+		}
+	}
+
 	return token, nil
 }
 
+// Проверяет, что в токене есть ключ пользователя (приложения) или хотя бы один ключ сообщества
+func (t *Token) hasAccessToken() bool {
+	if t.AccessToken != "" {
+		return true
+	}
+	for _, g := range t.Groups {
+		if g.AccessToken != "" {
+			return true
+		}
+	}
+	return false
+}
+
 // Возвращает версию API из конфига или берет значение по умолчанию
 func (v *Config) version() string {
 
@@ -197,12 +253,12 @@ func (v *Config) version() string {
 	return v.Version
 }
 
-// Создает URL для запроса на получение токена
-func (v *Config) buildTokenUrl(baseUrl string, opts ...AuthOption) string {
+// Создает URL для запроса на получение токена с секретным ключом secret
+func (v *Config) buildTokenUrl(baseUrl string, secret string, opts ...AuthOption) string {
 	u := url.Values{}
 
 	u.Set("client_id", v.ClientId)
-	u.Set("client_secret", v.ClientSecret)
+	u.Set("client_secret", secret)
 	u.Set("v", v.version())
 
 	for _, opt := range opts {
@@ -224,6 +280,7 @@ func (v *Config) buildTokenUrl(baseUrl string, opts ...AuthOption) string {
 }
 
 // Возвращает информацию об ошибке из URL
+// Язык описания берется из параметра lang редиректа, если он есть
 func (v *Config) getErrorFromQuery(query url.Values) error {
 	errCode := query.Get("error")
 	errDesc := query.Get("error_description")
@@ -231,6 +288,7 @@ func (v *Config) getErrorFromQuery(query url.Values) error {
 		return &TokenError{
 			Body:        []byte(query.Encode()),
 			ErrorCode:   errCode,
+			Lang:        query.Get("lang"),
 			description: errDesc,
 		}
 	}
@@ -2,6 +2,7 @@ package vkoauth_test
 
 import (
 	"context"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -24,12 +25,16 @@ func TestVkOauthDefaultValues(t *testing.T) {
 	c := vkoauth.Config{
 		ClientId:     "2274003",
 		ClientSecret: "secret",
-		Scope:        scope.Group.Photos | scope.User.Wall,
+		Scope:        scope.User.Photos | scope.User.Wall,
+		GroupScope:   scope.Group.Photos | scope.Group.Messages,
 		RedirectUri:  "blank.html",
 	}
 
 	t.Run("build implicit flow url", func(t *testing.T) {
-		url := c.ImplicitFlowAuthUrl(vkoauth.AuthParams{}, vkoauth.SetUrlParam("foo", "bar"))
+		url, err := c.ImplicitFlowAuthUrl(vkoauth.AuthParams{}, vkoauth.SetUrlParam("foo", "bar"))
+		if err != nil {
+			t.Fatal(err)
+		}
 		expectedUrl := "https://oauth.vk.com/authorize?client_id=2274003&foo=bar&redirect_uri=blank.html&response_type=token&scope=8196&v=5.131"
 		if url != expectedUrl {
 			t.Errorf("expected auth url: %q, real: %q", expectedUrl, url)
@@ -37,19 +42,55 @@ func TestVkOauthDefaultValues(t *testing.T) {
 	})
 
 	t.Run("build implicit flow url with params", func(t *testing.T) {
-		url := c.ImplicitFlowAuthUrl(vkoauth.AuthParams{
+		url, err := c.ImplicitFlowAuthUrl(vkoauth.AuthParams{
 			Revoke:   true,
 			State:    "1234",
 			GroupIds: []int64{1, 2, 3},
 			Display:  display.Popup,
 		}, vkoauth.SetUrlParam("foo", "bar"))
-		expectedUrl := "https://oauth.vk.com/authorize?client_id=2274003&display=popup&foo=bar&group_ids=1%2C2%2C3&redirect_uri=blank.html&response_type=token&revoke=1&scope=8196&state=1234&v=5.131"
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedUrl := "https://oauth.vk.com/authorize?client_id=2274003&display=popup&foo=bar&group_ids=1%2C2%2C3&redirect_uri=blank.html&response_type=token&revoke=1&scope=4100&state=1234&v=5.131"
 		if expectedUrl != url {
 			t.Errorf("expected auth url: %q, real: %q", expectedUrl, url)
 		}
 	})
 }
 
+func TestMixedScopeRejected(t *testing.T) {
+	userOnly := conf("")
+	_, err := userOnly.ImplicitFlowAuthUrl(vkoauth.AuthParams{GroupIds: []int64{1}})
+	if !errors.Is(err, vkoauth.ErrMixedScope) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	groupOnly := conf("")
+	groupOnly.Scope = 0
+	groupOnly.GroupScope = scope.Group.Manage
+	_, err = groupOnly.CodeFlowAuthUrl(vkoauth.AuthParams{})
+	if !errors.Is(err, vkoauth.ErrMixedScope) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	u, err := groupOnly.CodeFlowAuthUrl(vkoauth.AuthParams{GroupIds: []int64{1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u != "?client_id=CLIENT_ID&group_ids=1&redirect_uri=REDIRECT_URI&response_type=code&scope=262144&v=VERSION" {
+		t.Errorf("unexpected url: %q", u)
+	}
+}
+
+func TestInvalidDisplayRejected(t *testing.T) {
+	c := conf("")
+	_, err := c.ImplicitFlowAuthUrl(vkoauth.AuthParams{Display: "wap"})
+	invalid := &display.InvalidError{}
+	if !errors.As(err, &invalid) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
 func TestVkOauthExchangeRequest(t *testing.T) {
 	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != "POST" {
@@ -83,7 +124,10 @@ func TestVkOauthExchangeRequest(t *testing.T) {
 	c := conf(serv.URL)
 
 	t.Run("build implicit flow url", func(t *testing.T) {
-		url := c.ImplicitFlowAuthUrl(vkoauth.AuthParams{}, vkoauth.SetUrlParam("custom_parameter_key", "value"))
+		url, err := c.ImplicitFlowAuthUrl(vkoauth.AuthParams{}, vkoauth.SetUrlParam("custom_parameter_key", "value"))
+		if err != nil {
+			t.Fatal(err)
+		}
 		expectedUrl := serv.URL + "?client_id=CLIENT_ID&custom_parameter_key=value&redirect_uri=REDIRECT_URI&response_type=token&scope=8193&v=VERSION"
 		if url != expectedUrl {
 			t.Errorf("expected auth url: %q, real: %q", expectedUrl, url)
@@ -91,7 +135,10 @@ func TestVkOauthExchangeRequest(t *testing.T) {
 	})
 
 	t.Run("authorization code flow", func(t *testing.T) {
-		url := c.CodeFlowAuthUrl(vkoauth.AuthParams{})
+		url, err := c.CodeFlowAuthUrl(vkoauth.AuthParams{})
+		if err != nil {
+			t.Fatal(err)
+		}
 		expectedUrl := serv.URL + "?client_id=CLIENT_ID&redirect_uri=REDIRECT_URI&response_type=code&scope=8193&v=VERSION"
 		if url != expectedUrl {
 			t.Errorf("expected auth url: %q, real: %q", expectedUrl, url)
@@ -128,12 +175,57 @@ func TestTokenResponseParsingError(t *testing.T) {
 	}
 }
 
+func TestTokenResponseErrorWithStatusOk(t *testing.T) {
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"error":"invalid_grant","error_description":"Code is invalid or expired."}`))
+	}))
+	defer serv.Close()
+	c := conf(serv.URL)
+
+	token, err := c.ExchangeCode(context.Background(), "CODE")
+	errorObject, ok := err.(*vkoauth.TokenError)
+	if !ok {
+		t.Fatalf("unexpected result: %+v %v", token, err)
+	}
+	if errorObject.ErrorCode != "invalid_grant" || errorObject.Description() != "Code is invalid or expired." {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if errorObject.Response.StatusCode != http.StatusOK {
+		t.Errorf("unexpected status: %d", errorObject.Response.StatusCode)
+	}
+}
+
+func TestTokenResponseEmptyAccessToken(t *testing.T) {
+	for _, body := range []string{
+		`{"access_token":"","expires_in":0,"user_id":66748}`,
+		`{"expires_in":0}`,
+		`{"groups":[{"group_id":123456,"access_token":""}]}`,
+	} {
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}))
+		c := conf(serv.URL)
+
+		token, err := c.ExchangeCode(context.Background(), "CODE")
+		serv.Close()
+
+		errorObject, ok := err.(*vkoauth.TokenError)
+		if !ok {
+			t.Errorf("%s: unexpected result: %+v %v", body, token, err)
+			continue
+		}
+		if errorObject.ErrorCode != vkoauth.ErrorCodeEmptyToken || string(errorObject.Body) != body {
+			t.Errorf("%s: unexpected error: %v", body, err)
+		}
+	}
+}
+
 func conf(u string) vkoauth.Config {
 	return vkoauth.Config{
 		ClientId:     "CLIENT_ID",
 		ClientSecret: "CLIENT_SECRET",
 		Version:      "VERSION",
-		Scope:        scope.User.Wall | scope.Group.Stories,
+		Scope:        scope.User.Wall | scope.User.Notify,
 		RedirectUri:  "REDIRECT_URI",
 		Endpoint: &vkoauth.Endpoint{
 			AuthUrl:          u,
@@ -503,9 +595,12 @@ func TestClientCredentialsSponseSuccess(t *testing.T) {
 func TestRewriteImplicitFlowUrlValues(t *testing.T) {
 	c := conf("")
 
-	u := c.ImplicitFlowAuthUrl(vkoauth.AuthParams{
+	u, err := c.ImplicitFlowAuthUrl(vkoauth.AuthParams{
 		State: "origin_state",
 	}, vkoauth.SetUrlParam("state", "new_state"), vkoauth.SetUrlParam("client_id", "new_client_id"))
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if u != "?client_id=new_client_id&redirect_uri=REDIRECT_URI&response_type=token&scope=8193&state=new_state&v=VERSION" {
 		t.Errorf("unexpected url: %q", u)